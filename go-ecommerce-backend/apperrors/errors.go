@@ -0,0 +1,82 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrForbidden          = errors.New("forbidden")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrInvalidInput       = errors.New("invalid input")
+)
+
+type InsufficientStockError struct {
+	ProductID int
+	Available int
+	Requested int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for product %d: available %d, requested %d", e.ProductID, e.Available, e.Requested)
+}
+
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("Request body contains unknown field %q", e.Field)
+}
+
+func (e *UnknownFieldError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+type ImmutableFieldError struct {
+	Field string
+}
+
+func (e *ImmutableFieldError) Error() string {
+	return fmt.Sprintf("Field %q cannot be changed", e.Field)
+}
+
+func (e *ImmutableFieldError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+func DecodeError(err error) error {
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		return &UnknownFieldError{Field: strings.Trim(field, `"`)}
+	}
+	return InvalidInput(err.Error())
+}
+
+func NotFound(resource string) error {
+	return fmt.Errorf("%s %w", resource, ErrNotFound)
+}
+
+func Conflict(detail string) error {
+	return fmt.Errorf("%w: %s", ErrConflict, detail)
+}
+
+func Forbidden(detail string) error {
+	return fmt.Errorf("%w: %s", ErrForbidden, detail)
+}
+
+func PreconditionFailed(resource string) error {
+	return fmt.Errorf("%w: %s was modified by another request", ErrPreconditionFailed, resource)
+}
+
+func InvalidInput(detail string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidInput, detail)
+}
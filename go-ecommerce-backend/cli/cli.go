@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/database"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/logging"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+)
+
+const databaseName = "ecommerce"
+
+const usage = `Usage: go-ecommerce-backend [options] <command> [arguments]
+
+Options:
+  --config path                 Load settings from a YAML file (defaults to CONFIG_FILE)
+  --env-file path               Load environment variables from a dotenv file if it exists (default .env)
+  --set path=value              Override a setting, e.g. --set server.port=9090 (repeatable)
+
+Commands:
+  serve [--migrate] [--storage postgres|memory]
+                                Start the HTTP server
+  migrate up                    Apply all pending migrations
+  migrate down N|all            Roll back N migrations, or all of them
+  migrate goto V                Migrate up or down to version V
+  migrate force V               Mark version V as applied without running it
+  migrate status                Show the applied and latest migration versions
+  seed [--file path]            Load a fixture dataset
+  user create-admin --email E   Create an admin user (password from ADMIN_PASSWORD or ADMIN_PASSWORD_FILE)
+`
+
+var ErrUsage = errors.New("invalid usage")
+
+type command func(ctx context.Context, env *environment) error
+
+type environment struct {
+	cfg    *config.Config
+	logger *slog.Logger
+	stdout io.Writer
+}
+
+func usageError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrUsage, fmt.Sprintf(format, args...))
+}
+
+type settings []string
+
+func (s *settings) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *settings) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var sources config.Sources
+	global := newFlagSet("go-ecommerce-backend", stderr)
+	global.Usage = func() { fmt.Fprint(stderr, usage) }
+	global.StringVar(&sources.File, "config", "", "YAML config file")
+	global.StringVar(&sources.EnvFile, "env-file", ".env", "dotenv file")
+	global.Var((*settings)(&sources.Overrides), "set", "setting override")
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return usageError("%v", err)
+	}
+	args = global.Args()
+
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return usageError("missing command")
+	}
+
+	var run command
+	var err error
+	switch args[0] {
+	case "serve":
+		run, err = parseServe(args[1:], &sources, stderr)
+	case "migrate":
+		run, err = parseMigrate(args[1:])
+	case "seed":
+		run, err = parseSeed(args[1:], stderr)
+	case "user":
+		run, err = parseUser(args[1:], stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		err = usageError("unknown command %q", args[0])
+	}
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		fmt.Fprint(stderr, usage)
+		if !errors.Is(err, ErrUsage) {
+			err = usageError("%v", err)
+		}
+		return err
+	}
+
+	cfg, err := config.Load(sources)
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Logging.Level, cfg.Logging.Format)
+	if err != nil {
+		return fmt.Errorf("failed to configure logging: %w", err)
+	}
+	slog.SetDefault(logger)
+
+	return run(ctx, &environment{cfg: cfg, logger: logger, stdout: stdout})
+}
+
+func (env *environment) openDB(ctx context.Context) (*sql.DB, error) {
+	return database.Open(ctx, env.cfg.Database, env.logger)
+}
+
+func (env *environment) timeouts() repositories.Timeouts {
+	return repositories.Timeouts{Read: env.cfg.Database.ReadTimeout, Write: env.cfg.Database.WriteTimeout, Export: env.cfg.Database.ExportTimeout}
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/migrations"
+)
+
+func parseMigrate(args []string) (command, error) {
+	if len(args) == 0 {
+		return nil, usageError("migrate requires a subcommand")
+	}
+
+	var apply func(ctx context.Context, db *sql.DB) error
+	action, rest := args[0], args[1:]
+	switch action {
+	case "up", "status":
+		if len(rest) != 0 {
+			return nil, usageError("migrate %s takes no arguments", action)
+		}
+		if action == "up" {
+			apply = func(ctx context.Context, db *sql.DB) error { return migrations.RunMigrations(ctx, db, databaseName) }
+		}
+	case "down", "goto", "force":
+		if len(rest) != 1 {
+			return nil, usageError("migrate %s requires exactly one argument", action)
+		}
+		var err error
+		if apply, err = parseMigrateTarget(action, rest[0]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, usageError("unknown migrate subcommand %q", action)
+	}
+
+	return func(ctx context.Context, env *environment) error {
+		db, err := env.openDB(ctx)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if apply != nil {
+			if err := apply(ctx, db); err != nil {
+				return err
+			}
+		}
+		return printStatus(ctx, env, db)
+	}, nil
+}
+
+func parseMigrateTarget(action, arg string) (func(ctx context.Context, db *sql.DB) error, error) {
+	switch action {
+	case "down":
+		if arg == "all" {
+			return func(ctx context.Context, db *sql.DB) error {
+				return migrations.RollbackMigrations(ctx, db, databaseName)
+			}, nil
+		}
+		steps, err := strconv.Atoi(arg)
+		if err != nil || steps <= 0 {
+			return nil, usageError("migrate down expects a positive number of steps or \"all\", got %q", arg)
+		}
+		return func(ctx context.Context, db *sql.DB) error { return migrations.StepDown(ctx, db, databaseName, steps) }, nil
+	case "goto":
+		version, err := strconv.ParseUint(arg, 10, 0)
+		if err != nil || version == 0 {
+			return nil, usageError("migrate goto expects a positive version, got %q", arg)
+		}
+		return func(ctx context.Context, db *sql.DB) error {
+			return migrations.Goto(ctx, db, databaseName, uint(version))
+		}, nil
+	default:
+		version, err := strconv.Atoi(arg)
+		if err != nil || version < -1 {
+			return nil, usageError("migrate force expects a version or -1, got %q", arg)
+		}
+		return func(ctx context.Context, db *sql.DB) error { return migrations.Force(ctx, db, databaseName, version) }, nil
+	}
+}
+
+func printStatus(ctx context.Context, env *environment, db *sql.DB) error {
+	current, dirty, err := migrations.SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	latest, err := migrations.LatestVersion()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "version: %d\nlatest: %d\ndirty: %t\n", current, latest, dirty)
+	if current < latest {
+		fmt.Fprintf(env.stdout, "pending: %d\n", latest-current)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/seed"
+)
+
+func parseSeed(args []string, stderr io.Writer) (command, error) {
+	fs := newFlagSet("seed", stderr)
+	file := fs.String("file", "", "path to a fixtures JSON file (defaults to the embedded dataset)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 0 {
+		return nil, usageError("seed takes no positional arguments")
+	}
+
+	return func(ctx context.Context, env *environment) error {
+		fixtures, err := seed.DefaultFixtures()
+		if *file != "" {
+			fixtures, err = seed.LoadFixtures(*file)
+		}
+		if err != nil {
+			return err
+		}
+
+		db, err := env.openDB(ctx)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		summary, err := seed.NewSeeder(repositories.NewSQLRepositories(repositories.PrimaryOnly(db), env.timeouts())).Load(ctx, fixtures)
+		if errors.Is(err, seed.ErrAlreadySeeded) {
+			fmt.Fprintf(env.stdout, "skipped: %v\n", err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(env.stdout, "seeded %d products, %d users, %d customers, %d addresses, %d orders\n",
+			summary.Products, summary.Users, summary.Customers, summary.Addresses, summary.Orders)
+		return nil
+	}, nil
+}
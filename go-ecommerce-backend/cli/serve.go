@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/health"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/ratelimit"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/routes"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/tracing"
+)
+
+func parseServe(args []string, sources *config.Sources, stderr io.Writer) (command, error) {
+	fs := newFlagSet("serve", stderr)
+	migrate := fs.Bool("migrate", false, "apply pending migrations before serving (also enabled by features.auto_migrate)")
+	storage := fs.String("storage", "", "storage backend, postgres or memory (defaults to the storage setting)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 0 {
+		return nil, usageError("serve takes no positional arguments")
+	}
+	if *storage != "" {
+		sources.Overrides = append(sources.Overrides, "storage="+*storage)
+	}
+
+	return func(ctx context.Context, env *environment) error {
+		return serve(ctx, env, *migrate || env.cfg.Features.AutoMigrate)
+	}, nil
+}
+
+func serve(ctx context.Context, env *environment, migrate bool) error {
+	cfg, logger := env.cfg, env.logger
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to configure tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	repos, db, closeStorage, err := openStorage(ctx, env, migrate)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	checker := health.NewChecker(db, cfg.Server.HealthTimeout)
+
+	backend := cfg.RateLimit.Backend
+	if db == nil {
+		backend = ratelimit.BackendMemory
+	}
+	limiter, err := ratelimit.NewStore(backend, db)
+	if err != nil {
+		return fmt.Errorf("failed to configure rate limiting: %w", err)
+	}
+
+	go runMaintenance(ctx, repos.Idempotency, cfg.Idempotency.PurgeInterval, limiter, logger)
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           routes.SetupRoutes(repos, cfg, logger, checker, limiter),
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("server starting", "port", cfg.Server.Port)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	checker.SetDraining()
+	if cfg.Server.DrainDelay > 0 {
+		logger.Info("shutdown signal received, failing readiness before draining", "delay", cfg.Server.DrainDelay)
+		time.Sleep(cfg.Server.DrainDelay)
+	}
+
+	logger.Info("draining in-flight requests", "timeout", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	logger.Info("server stopped")
+	return nil
+}
+
+func runMaintenance(ctx context.Context, idempotencyRepo repositories.IdempotencyRepository, purgeInterval time.Duration, limiter ratelimit.Store, logger *slog.Logger) {
+	purge := time.NewTicker(purgeInterval)
+	defer purge.Stop()
+	sweep := time.NewTicker(time.Hour)
+	defer sweep.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-purge.C:
+			if deleted, err := idempotencyRepo.DeleteExpired(ctx); err != nil {
+				logger.Error("failed to purge expired idempotency keys", "error", err)
+			} else {
+				logger.Debug("purged expired idempotency keys", "deleted", deleted)
+			}
+		case <-sweep.C:
+			if deleted, err := limiter.Sweep(ctx, 24*time.Hour); err != nil {
+				logger.Error("failed to sweep idle rate limit buckets", "error", err)
+			} else {
+				logger.Debug("swept idle rate limit buckets", "deleted", deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/database"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/metrics"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/migrations"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/seed"
+)
+
+func openStorage(ctx context.Context, env *environment, migrate bool) (*repositories.Repositories, *sql.DB, func(), error) {
+	if env.cfg.Storage == config.StorageMemory {
+		repos, err := openMemoryStorage(ctx, env)
+		return repos, nil, func() {}, err
+	}
+
+	cfg, logger := env.cfg, env.logger
+	db, err := env.openDB(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	closers := []func() error{db.Close}
+	closeStorage := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			if err := closers[i](); err != nil {
+				logger.Error("failed to close database", "error", err)
+			}
+		}
+		logger.Info("database connections closed")
+	}
+	fail := func(err error) (*repositories.Repositories, *sql.DB, func(), error) {
+		closeStorage()
+		return nil, nil, nil, err
+	}
+
+	if err := metrics.RegisterDBStats(db, databaseName); err != nil {
+		return fail(fmt.Errorf("failed to register database metrics: %w", err))
+	}
+
+	if migrate {
+		if err := migrations.RunMigrations(ctx, db, databaseName); err != nil {
+			return fail(fmt.Errorf("migration failed: %w", err))
+		}
+		logger.Info("migrations applied")
+	}
+
+	var replica *sql.DB
+	if cfg.Database.ReplicaURL != "" {
+		if replica, err = database.OpenReplica(cfg.Database); err != nil {
+			return fail(err)
+		}
+		closers = append(closers, replica.Close)
+		if err := metrics.RegisterDBStats(replica, databaseName+"_replica"); err != nil {
+			return fail(fmt.Errorf("failed to register replica metrics: %w", err))
+		}
+	}
+	cluster := repositories.NewCluster(db, replica, repositories.ReplicaConfig{
+		MaxLag:        cfg.Database.ReplicaMaxLag,
+		Stickiness:    cfg.Database.ReplicaStickiness,
+		CheckInterval: cfg.Database.ReplicaCheckInterval,
+	})
+
+	go database.LogPoolStats(ctx, db, cfg.Database.StatsInterval, logger)
+	if replica != nil {
+		go database.LogPoolStats(ctx, replica, cfg.Database.StatsInterval, logger.With("pool", "replica"))
+		go cluster.Monitor(ctx, logger)
+	}
+
+	return repositories.NewSQLRepositories(cluster, env.timeouts()), db, closeStorage, nil
+}
+
+func openMemoryStorage(ctx context.Context, env *environment) (*repositories.Repositories, error) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+
+	fixtures, err := seed.DefaultFixtures()
+	if err != nil {
+		return nil, err
+	}
+	summary, err := seed.NewSeeder(repos).Load(ctx, fixtures)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed in-memory storage: %w", err)
+	}
+	env.logger.Warn("using in-memory storage, all data is lost when the server stops",
+		"products", summary.Products, "users", summary.Users, "orders", summary.Orders)
+	return repos, nil
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+type adminRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+func parseUser(args []string, stderr io.Writer) (command, error) {
+	if len(args) == 0 || args[0] != "create-admin" {
+		return nil, usageError("user requires the create-admin subcommand")
+	}
+
+	fs := newFlagSet("user create-admin", stderr)
+	email := fs.String("email", "", "email address of the new admin")
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	password, err := config.LookupEnv("ADMIN_PASSWORD")
+	if err != nil {
+		return nil, usageError("user create-admin: %v", err)
+	}
+	req := adminRequest{Email: *email, Password: password}
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, usageError("user create-admin: %v", err)
+	}
+
+	return func(ctx context.Context, env *environment) error {
+		db, err := env.openDB(ctx)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		authService := services.NewAuthService(repositories.NewUserRepository(db, env.timeouts()), repositories.NewTxManager(db, env.timeouts(), repositories.DefaultTxOptions), env.cfg.Auth.JWT())
+		user, err := authService.CreateUser(ctx, req.Email, req.Password, models.RoleAdmin)
+		if err != nil {
+			return fmt.Errorf("failed to create admin: %w", err)
+		}
+
+		fmt.Fprintf(env.stdout, "created admin %s with id %d\n", user.Email, user.ID)
+		return nil
+	}, nil
+}
@@ -1,39 +1,153 @@
 package config
 
 import (
-	"fmt"
-	"log"
-	"os"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/ratelimit"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/tracing"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+type ServerConfig struct {
+	Port              string        `yaml:"port" validate:"required,numeric"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" validate:"gt=0"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" validate:"gt=0"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" validate:"gt=0"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" validate:"gt=0"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" validate:"gt=0"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes" validate:"gt=0"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" validate:"gt=0"`
+	DrainDelay        time.Duration `yaml:"drain_delay" validate:"gte=0"`
+	HealthTimeout     time.Duration `yaml:"health_timeout" validate:"gt=0"`
+}
+
+type DatabaseConfig struct {
+	URL             string        `yaml:"url"`
+	TestURL         string        `yaml:"test_url"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" validate:"gt=0"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" validate:"gt=0"`
+	ExportTimeout   time.Duration `yaml:"export_timeout" validate:"gte=0"`
+	MaxOpenConns    int           `yaml:"max_open_conns" validate:"gte=0"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" validate:"gte=0"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" validate:"gte=0"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" validate:"gte=0"`
+
+	StatementTimeout  time.Duration `yaml:"statement_timeout" validate:"gte=0"`
+	ConnectTimeout    time.Duration `yaml:"connect_timeout" validate:"gt=0"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff" validate:"gt=0"`
+	ConnectMaxBackoff time.Duration `yaml:"connect_max_backoff" validate:"gt=0"`
+	StatsInterval     time.Duration `yaml:"stats_interval" validate:"gte=0"`
+
+	ReplicaURL           string        `yaml:"replica_url"`
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag" validate:"gt=0"`
+	ReplicaStickiness    time.Duration `yaml:"replica_stickiness" validate:"gte=0"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" validate:"gt=0"`
+}
+
+type AuthConfig struct {
+	JWTSecret string        `yaml:"jwt_secret" validate:"required"`
+	TokenTTL  time.Duration `yaml:"token_ttl" validate:"gt=0"`
+	Issuer    string        `yaml:"issuer"`
+}
+
+func (a AuthConfig) JWT() utils.JWTOptions {
+	return utils.JWTOptions{Secret: a.JWTSecret, TTL: a.TokenTTL, Issuer: a.Issuer}
+}
+
+type LoggingConfig struct {
+	Level  string `yaml:"level" validate:"oneof=debug info warn error"`
+	Format string `yaml:"format" validate:"oneof=json text"`
+}
+
+type IdempotencyConfig struct {
+	TTL           time.Duration `yaml:"ttl" validate:"gt=0"`
+	Lease         time.Duration `yaml:"lease" validate:"gt=0"`
+	PurgeInterval time.Duration `yaml:"purge_interval" validate:"gt=0"`
+}
+
+type FeatureConfig struct {
+	RequireIfMatch bool `yaml:"require_if_match"`
+	AutoMigrate    bool `yaml:"auto_migrate"`
+}
+
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
 )
 
 type Config struct {
-	DBProd    string
-	DBTest    string
-	JWTSecret string
+	Storage     string            `yaml:"storage" validate:"oneof=postgres memory"`
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Tracing     tracing.Config    `yaml:"tracing"`
+	RateLimit   ratelimit.Config  `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Features    FeatureConfig     `yaml:"features"`
 }
 
-func LoadConfig(path string) (*Config, error) {
-	err := godotenv.Load(path)
+const defaultRateLimitPolicies = "default: 120/1m burst=60 key=user; POST /v1/login: 10/1m key=ip; POST /v1/register: 5/1h key=ip; GET /v1/products: 60/1m burst=30 key=ip"
+
+func Default() *Config {
+	policies, err := ratelimit.ParsePolicies(defaultRateLimitPolicies)
 	if err != nil {
-		log.Println("No .env file found, using system environment variables")
+		panic(err)
 	}
 
-	cfg := &Config{
-		DBProd:    os.Getenv("DB_PROD"),
-		DBTest:    os.Getenv("DB_TEST"),
-		JWTSecret: os.Getenv("JWT_SECRET"),
-	}
+	return &Config{
+		Storage: StoragePostgres,
+		Server: ServerConfig{
+			Port:              "8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      1 << 20,
+			ShutdownTimeout:   20 * time.Second,
+			HealthTimeout:     2 * time.Second,
+		},
+		Database: DatabaseConfig{
+			ReadTimeout:     5 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
 
-	if cfg.DBProd == "" {
-		return nil, fmt.Errorf("DB_PROD environment variable is required")
-	}
-	if cfg.DBTest == "" {
-		return nil, fmt.Errorf("DB_TEST environment variable is required")
-	}
-	if cfg.JWTSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET environment variable is required")
+			StatementTimeout:  30 * time.Second,
+			ConnectTimeout:    30 * time.Second,
+			ConnectBackoff:    500 * time.Millisecond,
+			ConnectMaxBackoff: 5 * time.Second,
+			StatsInterval:     time.Minute,
+
+			ReplicaMaxLag:        5 * time.Second,
+			ReplicaStickiness:    10 * time.Second,
+			ReplicaCheckInterval: 5 * time.Second,
+		},
+		Auth: AuthConfig{
+			TokenTTL: 72 * time.Hour,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: tracing.Config{
+			Exporter:     tracing.ExporterNone,
+			OTLPEndpoint: "localhost:4318",
+			ServiceName:  "go-ecommerce-backend",
+			SampleRatio:  1,
+		},
+		RateLimit: ratelimit.Config{
+			Enabled:  true,
+			Backend:  ratelimit.BackendMemory,
+			Policies: policies,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:           24 * time.Hour,
+			Lease:         time.Minute,
+			PurgeInterval: time.Hour,
+		},
 	}
-	return cfg, nil
 }
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+type Sources struct {
+	File      string
+	EnvFile   string
+	Overrides []string
+}
+
+var envVars = []struct{ path, name string }{
+	{"storage", "STORAGE"},
+
+	{"server.port", "PORT"},
+	{"server.read_timeout", "SERVER_READ_TIMEOUT"},
+	{"server.read_header_timeout", "SERVER_READ_HEADER_TIMEOUT"},
+	{"server.write_timeout", "SERVER_WRITE_TIMEOUT"},
+	{"server.idle_timeout", "SERVER_IDLE_TIMEOUT"},
+	{"server.max_header_bytes", "SERVER_MAX_HEADER_BYTES"},
+	{"server.max_body_bytes", "MAX_BODY_BYTES"},
+	{"server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT"},
+	{"server.drain_delay", "SERVER_DRAIN_DELAY"},
+	{"server.health_timeout", "HEALTH_CHECK_TIMEOUT"},
+
+	{"database.url", "DB_PROD"},
+	{"database.test_url", "DB_TEST"},
+	{"database.read_timeout", "DB_READ_TIMEOUT"},
+	{"database.write_timeout", "DB_WRITE_TIMEOUT"},
+	{"database.export_timeout", "DB_EXPORT_TIMEOUT"},
+	{"database.max_open_conns", "DB_MAX_OPEN_CONNS"},
+	{"database.max_idle_conns", "DB_MAX_IDLE_CONNS"},
+	{"database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME"},
+	{"database.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME"},
+	{"database.statement_timeout", "DB_STATEMENT_TIMEOUT"},
+	{"database.connect_timeout", "DB_CONNECT_TIMEOUT"},
+	{"database.connect_backoff", "DB_CONNECT_BACKOFF"},
+	{"database.connect_max_backoff", "DB_CONNECT_MAX_BACKOFF"},
+	{"database.stats_interval", "DB_STATS_INTERVAL"},
+	{"database.replica_url", "DB_REPLICA"},
+	{"database.replica_max_lag", "DB_REPLICA_MAX_LAG"},
+	{"database.replica_stickiness", "DB_REPLICA_STICKINESS"},
+	{"database.replica_check_interval", "DB_REPLICA_CHECK_INTERVAL"},
+
+	{"auth.jwt_secret", "JWT_SECRET"},
+	{"auth.token_ttl", "JWT_TOKEN_TTL"},
+	{"auth.issuer", "JWT_ISSUER"},
+
+	{"logging.level", "LOG_LEVEL"},
+	{"logging.format", "LOG_FORMAT"},
+
+	{"tracing.exporter", "TRACING_EXPORTER"},
+	{"tracing.otlp_endpoint", "TRACING_OTLP_ENDPOINT"},
+	{"tracing.otlp_insecure", "TRACING_OTLP_INSECURE"},
+	{"tracing.service_name", "TRACING_SERVICE_NAME"},
+	{"tracing.sample_ratio", "TRACING_SAMPLE_RATIO"},
+
+	{"rate_limit.enabled", "RATE_LIMIT_ENABLED"},
+	{"rate_limit.backend", "RATE_LIMIT_BACKEND"},
+	{"rate_limit.trusted_proxies", "RATE_LIMIT_TRUSTED_PROXIES"},
+	{"rate_limit.policies", "RATE_LIMIT_POLICIES"},
+
+	{"idempotency.ttl", "IDEMPOTENCY_TTL"},
+	{"idempotency.lease", "IDEMPOTENCY_LEASE"},
+	{"idempotency.purge_interval", "IDEMPOTENCY_PURGE_INTERVAL"},
+
+	{"features.require_if_match", "REQUIRE_IF_MATCH"},
+	{"features.auto_migrate", "AUTO_MIGRATE"},
+}
+
+func Load(src Sources) (*Config, error) {
+	if src.EnvFile != "" {
+		if err := godotenv.Load(src.EnvFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read env file %s: %w", src.EnvFile, err)
+		}
+	}
+
+	cfg := Default()
+	file := src.File
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	if file != "" {
+		if err := cfg.loadFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	errs := cfg.applyEnv()
+	errs = append(errs, cfg.applyOverrides(src.Overrides)...)
+	for name, policy := range cfg.RateLimit.Policies {
+		policy.Name = name
+		cfg.RateLimit.Policies[name] = policy
+	}
+	errs = append(errs, cfg.validate()...)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	defaults := c.RateLimit.Policies
+	c.RateLimit.Policies = nil
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if c.RateLimit.Policies == nil {
+		c.RateLimit.Policies = defaults
+	}
+	return nil
+}
+
+func (c *Config) applyEnv() []error {
+	var errs []error
+	for _, env := range envVars {
+		value, err := LookupEnv(env.name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if value == "" {
+			continue
+		}
+		if err := c.set(env.path, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", env.name, err))
+		}
+	}
+	return errs
+}
+
+func LookupEnv(name string) (string, error) {
+	value := os.Getenv(name)
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return value, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("%s and %s_FILE are both set, use only one", name, name)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(contents), "\r\n"), nil
+}
+
+func (c *Config) applyOverrides(overrides []string) []error {
+	var errs []error
+	for _, override := range overrides {
+		path, value, found := strings.Cut(override, "=")
+		if !found {
+			errs = append(errs, fmt.Errorf("--set %s: expected path=value", override))
+			continue
+		}
+		if err := c.set(strings.TrimSpace(path), value); err != nil {
+			errs = append(errs, fmt.Errorf("--set %s: %w", path, err))
+		}
+	}
+	return errs
+}
+
+func (c *Config) set(path string, value string) error {
+	field := reflect.ValueOf(c).Elem()
+	for _, name := range strings.Split(path, ".") {
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("unknown setting %q", path)
+		}
+		next, ok := fieldByTag(field, name)
+		if !ok {
+			return fmt.Errorf("unknown setting %q", path)
+		}
+		field = next
+	}
+	return setValue(field, value)
+}
+
+func fieldByTag(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("yaml"), ",")
+		if tag == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setValue(v reflect.Value, value string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 5s")
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		v.SetInt(i)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot be set from a string")
+	}
+	return nil
+}
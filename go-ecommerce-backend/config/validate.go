@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+func (c *Config) Validate() error {
+	return errors.Join(c.validate()...)
+}
+
+func (c *Config) validate() []error {
+	var errs []error
+
+	var validationErrors validator.ValidationErrors
+	if err := validate.Struct(c); errors.As(err, &validationErrors) {
+		for _, fe := range validationErrors {
+			errs = append(errs, fmt.Errorf("%s %s", settingName(fe), describe(fe)))
+		}
+	} else if err != nil {
+		errs = append(errs, err)
+	}
+
+	db := c.Database
+	if c.Storage == StoragePostgres && db.URL == "" {
+		errs = append(errs, fmt.Errorf("database.url is required"))
+	}
+	if db.MaxOpenConns > 0 && db.MaxIdleConns > db.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("database.max_idle_conns must not exceed database.max_open_conns"))
+	}
+	if db.ConnectMaxBackoff < db.ConnectBackoff {
+		errs = append(errs, fmt.Errorf("database.connect_max_backoff must be at least database.connect_backoff"))
+	}
+	return errs
+}
+
+func settingName(fe validator.FieldError) string {
+	_, name, _ := strings.Cut(fe.Namespace(), ".")
+	return name
+}
+
+func describe(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "numeric":
+		return "must be numeric"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " check"
+	}
+}
@@ -1,12 +1,13 @@
 package controllers
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
 )
 
 type AddressController struct {
@@ -23,72 +24,94 @@ func (ac *AddressController) GetAddress(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid address ID")
 		return
 	}
-	address, err := ac.AddressService.GetAddressByID(id)
+	address, err := ac.AddressService.GetAddressByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Address not found", http.StatusNotFound)
+		problems.WriteError(w, r, err)
+		return
 	}
-	json.NewEncoder(w).Encode(address)
+	setETag(w, address.Version)
+	utils.WriteJSON(w, r, address)
 }
 
 func (ac *AddressController) GetAddressesByCustomerID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid customer ID")
 		return
 	}
-	addresses, err := ac.AddressService.GetAddressesByCustomerID(id)
+	addresses, err := ac.AddressService.GetAddressesByCustomerID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Addresses for customer not found", http.StatusNotFound)
+		problems.WriteError(w, r, err)
+		return
 	}
-	json.NewEncoder(w).Encode(addresses)
+	utils.WriteJSON(w, r, addresses)
 }
 
 func (ac *AddressController) CreateAddress(w http.ResponseWriter, r *http.Request, req *services.AddressRequest) {
-	address, err := ac.AddressService.CreateAddress(req)
+	address, err := ac.AddressService.CreateAddress(r.Context(), req)
 
 	if err != nil {
-		http.Error(w, "Failed to create address", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 
+	setETag(w, address.Version)
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(address)
+	utils.WriteJSON(w, r, address)
 }
 
 func (ac *AddressController) UpdateAddress(w http.ResponseWriter, r *http.Request, req *services.AddressRequest) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid address ID")
 		return
 	}
 
-	address, err := ac.AddressService.UpdateAddress(id, req)
+	address, err := ac.AddressService.UpdateAddress(r.Context(), id, req)
 
 	if err != nil {
-		http.Error(w, "Failed to update address", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(address)
+	setETag(w, address.Version)
+	utils.WriteJSON(w, r, address)
+}
+
+func (ac *AddressController) PatchAddress(w http.ResponseWriter, r *http.Request, patch []byte) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid address ID")
+		return
+	}
+
+	address, err := ac.AddressService.PatchAddress(r.Context(), id, patch)
+	if err != nil {
+		problems.WriteError(w, r, err)
+		return
+	}
+	setETag(w, address.Version)
+	utils.WriteJSON(w, r, address)
 }
 
 func (ac *AddressController) DeleteAddress(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid address ID")
 		return
 	}
 
-	err = ac.AddressService.DeleteAddress(id)
+	err = ac.AddressService.DeleteAddress(r.Context(), id)
 
 	if err != nil {
-		http.Error(w, "Failed to delete address", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
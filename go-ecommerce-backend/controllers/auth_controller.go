@@ -1,10 +1,11 @@
 package controllers
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
 )
 
 type AuthController struct {
@@ -18,9 +19,9 @@ func NewAuthController(authService services.AuthService) *AuthController {
 }
 
 func (a *AuthController) Login(w http.ResponseWriter, r *http.Request, req *services.LoginRequest) {
-	user, token, err := a.AuthService.Login(req)
+	user, token, err := a.AuthService.Login(r.Context(), req)
 	if err != nil {
-		http.Error(w, "Authentication failed", http.StatusUnauthorized)
+		problems.WriteError(w, r, err)
 		return
 	}
 
@@ -30,18 +31,16 @@ func (a *AuthController) Login(w http.ResponseWriter, r *http.Request, req *serv
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-	}
+	utils.WriteJSON(w, r, response)
 }
 
 func (a *AuthController) Register(w http.ResponseWriter, r *http.Request, req *services.RegisterRequest) {
-	err := a.AuthService.Register(req)
+	err := a.AuthService.Register(r.Context(), req)
 	if err != nil {
-		http.Error(w, "Registration failed", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{"message": "User registered successfully"})
+	utils.WriteJSON(w, r, map[string]string{"message": "User registered successfully"})
 }
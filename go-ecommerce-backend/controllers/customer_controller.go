@@ -1,12 +1,13 @@
 package controllers
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
 )
 
 type CustomerController struct {
@@ -23,72 +24,93 @@ func (cc *CustomerController) GetCustomer(w http.ResponseWriter, r *http.Request
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid customer ID")
 		return
 	}
 
-	customer, err := cc.CustomerService.GetCustomerByID(id)
+	customer, err := cc.CustomerService.GetCustomerByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Customer not found", http.StatusNotFound)
+		problems.WriteError(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(customer)
+	setETag(w, customer.Version)
+	utils.WriteJSON(w, r, customer)
 }
 
 func (cc *CustomerController) GetCustomerByUserID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid user ID")
 		return
 	}
 
-	customer, err := cc.CustomerService.GetCustomerByUserID(id)
+	customer, err := cc.CustomerService.GetCustomerByUserID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Customer not found", http.StatusNotFound)
+		problems.WriteError(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(customer)
+	setETag(w, customer.Version)
+	utils.WriteJSON(w, r, customer)
 }
 
 func (cc *CustomerController) CreateCustomer(w http.ResponseWriter, r *http.Request, req *services.CustomerRequest) {
-	customer, err := cc.CustomerService.CreateCustomer(req)
+	customer, err := cc.CustomerService.CreateCustomer(r.Context(), req)
 	if err != nil {
-		http.Error(w, "Failed to create customer", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 
+	setETag(w, customer.Version)
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(customer)
+	utils.WriteJSON(w, r, customer)
 }
 
 func (cc *CustomerController) UpdateCustomer(w http.ResponseWriter, r *http.Request, req *services.CustomerRequest) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid customer ID")
 		return
 	}
 
-	customer, err := cc.CustomerService.UpdateCustomer(id, req)
+	customer, err := cc.CustomerService.UpdateCustomer(r.Context(), id, req)
 	if err != nil {
-		http.Error(w, "Failed to update customer", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(customer)
+	setETag(w, customer.Version)
+	utils.WriteJSON(w, r, customer)
+}
+
+func (cc *CustomerController) PatchCustomer(w http.ResponseWriter, r *http.Request, patch []byte) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid customer ID")
+		return
+	}
+
+	customer, err := cc.CustomerService.PatchCustomer(r.Context(), id, patch)
+	if err != nil {
+		problems.WriteError(w, r, err)
+		return
+	}
+	setETag(w, customer.Version)
+	utils.WriteJSON(w, r, customer)
 }
 
 func (cc *CustomerController) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid customer ID")
 		return
 	}
 
-	err = cc.CustomerService.DeleteCustomer(id)
+	err = cc.CustomerService.DeleteCustomer(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Failed to delete customer", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
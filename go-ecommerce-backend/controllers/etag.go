@@ -0,0 +1,11 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/preconditions"
+)
+
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", preconditions.ETag(version))
+}
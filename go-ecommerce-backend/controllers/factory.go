@@ -1,8 +1,6 @@
 package controllers
 
 import (
-	"database/sql"
-
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
@@ -17,19 +15,13 @@ type AllControllers struct {
 	OrderController    *OrderController
 }
 
-func NewControllers(db *sql.DB, cfg *config.Config) *AllControllers {
-	userRepo := repositories.NewUserRepository(db)
-	customerRepo := repositories.NewCustomerRepository(db)
-	addressRepo := repositories.NewAddressRepository(db)
-	productRepo := repositories.NewProductRepository(db)
-	orderRepo := repositories.NewOrderRepository(db)
-
-	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
-	userService := services.NewUserService(userRepo)
-	customerService := services.NewCustomerService(customerRepo)
-	addressService := services.NewAddressService(addressRepo)
-	productService := services.NewProductService(productRepo)
-	orderService := services.NewOrderService(orderRepo)
+func NewControllers(repos *repositories.Repositories, cfg *config.Config) *AllControllers {
+	authService := services.NewAuthService(repos.Users, repos.Tx, cfg.Auth.JWT())
+	userService := services.NewUserService(repos.Users)
+	customerService := services.NewCustomerService(repos.Customers)
+	addressService := services.NewAddressService(repos.Addresses)
+	productService := services.NewProductService(repos.Products)
+	orderService := services.NewOrderService(repos.Orders, repos.Customers, repos.Addresses, repos.Tx)
 
 	return &AllControllers{
 		AuthController:     NewAuthController(authService),
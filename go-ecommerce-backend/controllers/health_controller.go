@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/health"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+type HealthController struct {
+	Checker *health.Checker
+}
+
+func NewHealthController(checker *health.Checker) *HealthController {
+	return &HealthController{
+		Checker: checker,
+	}
+}
+
+func (hc *HealthController) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	utils.WriteJSON(w, r, map[string]string{"status": health.StatusOK})
+}
+
+func (hc *HealthController) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := hc.Checker.Readiness(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != health.StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	utils.WriteJSON(w, r, report)
+}
@@ -1,12 +1,18 @@
 package controllers
 
 import (
-	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
 )
 
 type OrderController struct {
@@ -23,57 +29,184 @@ func (oc *OrderController) GetOrder(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid order ID")
 		return
 	}
 
-	order, err := oc.OrderService.GetOrderByID(id)
+	order, err := oc.OrderService.GetOrderByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Order not found", http.StatusNotFound)
+		problems.WriteError(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(order)
+	setETag(w, order.Version)
+	utils.WriteJSON(w, r, order)
 }
 
 func (oc *OrderController) GetOrdersByCustomerID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid customer ID")
 		return
 	}
 
-	order, err := oc.OrderService.GetOrdersByCustomerID(id)
+	query, err := parseOrderListQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w, "Orders for customer not found", http.StatusNotFound)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidQuery, err.Error())
 		return
 	}
-	json.NewEncoder(w).Encode(order)
+
+	page, err := oc.OrderService.GetOrdersByCustomerID(r.Context(), id, query)
+	if err != nil {
+		problems.WriteError(w, r, err)
+		return
+	}
+	utils.WriteJSON(w, r, orderPageBody(w, r, page, query.Summary))
+}
+
+func (oc *OrderController) ListOrders(w http.ResponseWriter, r *http.Request) {
+	query, err := parseOrderListQuery(r.URL.Query())
+	if err != nil {
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidQuery, err.Error())
+		return
+	}
+
+	page, err := oc.OrderService.ListOrders(r.Context(), query)
+	if err != nil {
+		problems.WriteError(w, r, err)
+		return
+	}
+	utils.WriteJSON(w, r, orderPageBody(w, r, page, query.Summary))
+}
+
+func (oc *OrderController) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	for _, param := range []string{"limit", "cursor", "view"} {
+		if r.URL.Query().Has(param) {
+			problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidQuery, param+" is not supported by the export")
+			return
+		}
+	}
+	query, err := parseOrderListQuery(r.URL.Query())
+	if err != nil {
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidQuery, err.Error())
+		return
+	}
+	encoder, ok := newOrderEncoder(r.URL.Query().Get("format"), w)
+	if !ok {
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidQuery, "format must be csv or ndjson")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	var started bool
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		w.Header().Set("Content-Type", encoder.ContentType())
+		w.Header().Set("Content-Disposition", `attachment; filename="orders.`+encoder.Extension()+`"`)
+		w.WriteHeader(http.StatusOK)
+		return encoder.Begin()
+	}
+
+	var exported int
+	err = oc.OrderService.ExportOrders(r.Context(), query, func(order *models.Order) error {
+		if err := start(); err != nil {
+			return err
+		}
+		if err := encoder.Encode(order); err != nil {
+			return err
+		}
+		if exported++; exported%exportFlushEvery == 0 {
+			if err := encoder.Flush(); err != nil {
+				return err
+			}
+			rc.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = start()
+	}
+	if err == nil {
+		err = encoder.Flush()
+	}
+	if err != nil {
+		if !started {
+			problems.WriteError(w, r, err)
+			return
+		}
+		slog.ErrorContext(r.Context(), "order export failed", "exported", exported, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+	slog.InfoContext(r.Context(), "orders exported", "format", encoder.Extension(), "exported", exported)
+}
+
+type OrderStatusResult struct {
+	ID    int               `json:"id"`
+	Order *models.Order     `json:"order,omitempty"`
+	Error *problems.Problem `json:"error,omitempty"`
+}
+
+type OrderStatusesResponse struct {
+	Updated int                 `json:"updated"`
+	Failed  int                 `json:"failed"`
+	Results []OrderStatusResult `json:"results"`
+}
+
+func (oc *OrderController) UpdateOrderStatuses(w http.ResponseWriter, r *http.Request, req *services.OrderStatusesRequest) {
+	if r.Header.Get("If-Match") != "" {
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidBody, "If-Match cannot apply to several orders, send the expected versions in versions instead")
+		return
+	}
+	for id := range req.Versions {
+		if !slices.Contains(req.OrderIDs, id) {
+			problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidBody, fmt.Sprintf("versions contains order %d, which is not in order_ids", id))
+			return
+		}
+	}
+
+	var resp OrderStatusesResponse
+	for _, result := range oc.OrderService.UpdateOrderStatuses(r.Context(), req) {
+		if result.Err != nil {
+			resp.Failed++
+			resp.Results = append(resp.Results, OrderStatusResult{ID: result.ID, Error: problems.FromError(r, result.Err)})
+			continue
+		}
+		resp.Updated++
+		resp.Results = append(resp.Results, OrderStatusResult{ID: result.ID, Order: result.Order})
+	}
+	utils.WriteJSON(w, r, resp)
 }
 
 func (oc *OrderController) CreateOrder(w http.ResponseWriter, r *http.Request, req *services.OrderRequest) {
-	order, err := oc.OrderService.CreateOrder(req)
+	order, err := oc.OrderService.CreateOrder(r.Context(), req)
 	if err != nil {
-		http.Error(w, "Failed to create order", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 
+	setETag(w, order.Version)
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(order)
+	utils.WriteJSON(w, r, order)
 }
 
 func (oc *OrderController) UpdateOrder(w http.ResponseWriter, r *http.Request, req *services.OrderRequest) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid order ID")
 		return
 	}
 
-	order, err := oc.OrderService.UpdateOrder(id, req)
+	order, err := oc.OrderService.UpdateOrder(r.Context(), id, req)
 	if err != nil {
-		http.Error(w, "Failed to update order", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(order)
+	setETag(w, order.Version)
+	utils.WriteJSON(w, r, order)
 }
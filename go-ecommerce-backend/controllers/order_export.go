@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+)
+
+const exportFlushEvery = 100
+
+type orderEncoder interface {
+	ContentType() string
+	Extension() string
+	Begin() error
+	Encode(order *models.Order) error
+	Flush() error
+}
+
+func newOrderEncoder(format string, w io.Writer) (orderEncoder, bool) {
+	switch format {
+	case "", "csv":
+		return &csvOrderEncoder{w: csv.NewWriter(w)}, true
+	case "ndjson":
+		return &ndjsonOrderEncoder{enc: json.NewEncoder(w)}, true
+	default:
+		return nil, false
+	}
+}
+
+type csvOrderEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvOrderEncoder) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (e *csvOrderEncoder) Extension() string { return "csv" }
+
+func (e *csvOrderEncoder) Begin() error {
+	header := []string{"id", "customer_id", "status", "total", "created_at", "version"}
+	header = append(header, addressColumns("shipping")...)
+	header = append(header, addressColumns("billing")...)
+	return e.w.Write(header)
+}
+
+func (e *csvOrderEncoder) Encode(order *models.Order) error {
+	record := []string{
+		strconv.Itoa(order.ID),
+		strconv.Itoa(order.CustomerID),
+		string(order.Status),
+		strconv.FormatFloat(order.Total, 'f', 2, 64),
+		order.CreatedAt.UTC().Format(time.RFC3339),
+		strconv.Itoa(order.Version),
+	}
+	record = append(record, addressValues(order.ShippingAddress)...)
+	record = append(record, addressValues(order.BillingAddress)...)
+	return e.w.Write(record)
+}
+
+func addressColumns(prefix string) []string {
+	return []string{prefix + "_address_id", prefix + "_street_address", prefix + "_city", prefix + "_country"}
+}
+
+func addressValues(address *models.OrderAddress) []string {
+	if address == nil {
+		return []string{"", "", "", ""}
+	}
+	return []string{strconv.Itoa(address.AddressID), csvText(address.StreetAddress), csvText(address.City), csvText(address.Country)}
+}
+
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (e *csvOrderEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonOrderEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonOrderEncoder) ContentType() string { return "application/x-ndjson" }
+
+func (e *ndjsonOrderEncoder) Extension() string { return "ndjson" }
+
+func (e *ndjsonOrderEncoder) Begin() error { return nil }
+
+func (e *ndjsonOrderEncoder) Encode(order *models.Order) error {
+	return e.enc.Encode(order.Summary())
+}
+
+func (e *ndjsonOrderEncoder) Flush() error { return nil }
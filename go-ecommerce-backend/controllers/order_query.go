@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+)
+
+func parseOrderListQuery(values url.Values) (services.OrderListQuery, error) {
+	var query services.OrderListQuery
+	var err error
+
+	if statuses := values.Get("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			switch status := models.OrderStatus(strings.TrimSpace(status)); status {
+			case models.OrderStatusPending, models.OrderStatusCompleted, models.OrderStatusCancelled:
+				query.Statuses = append(query.Statuses, status)
+			default:
+				return query, errors.New("status must be a comma-separated list of pending, completed, cancelled")
+			}
+		}
+	}
+	if query.CustomerID, err = parseIDParam(values, "customer_id"); err != nil {
+		return query, err
+	}
+	if query.ProductID, err = parseIDParam(values, "product_id"); err != nil {
+		return query, err
+	}
+	if query.MinTotal, err = parseAmountParam(values, "min_total"); err != nil {
+		return query, err
+	}
+	if query.MaxTotal, err = parseAmountParam(values, "max_total"); err != nil {
+		return query, err
+	}
+	if query.CreatedAfter, err = parseTimeParam(values, "created_after"); err != nil {
+		return query, err
+	}
+	if query.CreatedBefore, err = parseTimeParam(values, "created_before"); err != nil {
+		return query, err
+	}
+
+	sort := values.Get("sort")
+	query.Ascending = sort != "" && !strings.HasPrefix(sort, "-")
+	switch strings.TrimPrefix(sort, "-") {
+	case "", "created_at":
+		query.Sort = repositories.OrderSortCreatedAt
+	case "total":
+		query.Sort = repositories.OrderSortTotal
+	default:
+		return query, errors.New("sort must be one of created_at, -created_at, total, -total")
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 || query.Limit > services.MaxOrderPageSize {
+			return query, fmt.Errorf("limit must be an integer between 1 and %d", services.MaxOrderPageSize)
+		}
+	}
+	if cursor := values.Get("cursor"); cursor != "" {
+		if query.Cursor, err = services.DecodeOrderCursor(cursor); err != nil {
+			return query, errors.New("cursor is not valid")
+		}
+		if query.Cursor.Sort != query.Sort || query.Cursor.Ascending != query.Ascending {
+			return query, errors.New("cursor was issued for a different sort order")
+		}
+	}
+
+	switch values.Get("view") {
+	case "", "full":
+	case "summary":
+		query.Summary = true
+	default:
+		return query, errors.New("view must be full or summary")
+	}
+	return query, nil
+}
+
+func parseIDParam(values url.Values, name string) (int, error) {
+	value := values.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return id, nil
+}
+
+func parseAmountParam(values url.Values, name string) (*float64, error) {
+	value := values.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return nil, fmt.Errorf("%s must be a non-negative number", name)
+	}
+	return &amount, nil
+}
+
+func parseTimeParam(values url.Values, name string) (time.Time, error) {
+	value := values.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return t, nil
+}
+
+func orderPageBody(w http.ResponseWriter, r *http.Request, page *services.OrderPage, summary bool) any {
+	if page.NextCursor != "" {
+		next := *r.URL
+		values := next.Query()
+		values.Set("cursor", page.NextCursor)
+		next.RawQuery = values.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+	if !summary {
+		return page.Orders
+	}
+	summaries := make([]models.OrderSummary, len(page.Orders))
+	for i, order := range page.Orders {
+		summaries[i] = order.Summary()
+	}
+	return summaries
+}
@@ -1,12 +1,13 @@
 package controllers
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
 )
 
 type ProductController struct {
@@ -23,65 +24,85 @@ func (pc *ProductController) GetProduct(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid product ID")
 		return
 	}
 
-	product, err := pc.ProductService.GetProductByID(id)
+	product, err := pc.ProductService.GetProductByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Product not found", http.StatusNotFound)
+		problems.WriteError(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(product)
+	setETag(w, product.Version)
+	utils.WriteJSON(w, r, product)
 }
 
 func (pc *ProductController) GetAllProducts(w http.ResponseWriter, r *http.Request) {
-	orders, err := pc.ProductService.GetAllProducts()
+	orders, err := pc.ProductService.GetAllProducts(r.Context())
 	if err != nil {
-		http.Error(w, "Error fetching products", http.StatusNotFound)
+		problems.WriteError(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(orders)
+	utils.WriteJSON(w, r, orders)
 }
 
 func (pc *ProductController) CreateProduct(w http.ResponseWriter, r *http.Request, req *services.ProductRequest) {
-	product, err := pc.ProductService.CreateProduct(req)
+	product, err := pc.ProductService.CreateProduct(r.Context(), req)
 	if err != nil {
-		http.Error(w, "Failed to create product", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 
+	setETag(w, product.Version)
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(product)
+	utils.WriteJSON(w, r, product)
 }
 
 func (pc *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request, req *services.ProductRequest) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid product ID")
 		return
 	}
 
-	product, err := pc.ProductService.UpdateProduct(id, req)
+	product, err := pc.ProductService.UpdateProduct(r.Context(), id, req)
 	if err != nil {
-		http.Error(w, "Failed to update product", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(product)
+	setETag(w, product.Version)
+	utils.WriteJSON(w, r, product)
+}
+
+func (pc *ProductController) PatchProduct(w http.ResponseWriter, r *http.Request, patch []byte) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid product ID")
+		return
+	}
+
+	product, err := pc.ProductService.PatchProduct(r.Context(), id, patch)
+	if err != nil {
+		problems.WriteError(w, r, err)
+		return
+	}
+	setETag(w, product.Version)
+	utils.WriteJSON(w, r, product)
 }
 
 func (pc *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid product ID")
 		return
 	}
 
-	err = pc.ProductService.DeleteProduct(id)
+	err = pc.ProductService.DeleteProduct(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Failed to delete product", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -1,12 +1,13 @@
 package controllers
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
 )
 
 type UserController struct {
@@ -24,17 +25,18 @@ func (uc *UserController) GetUser(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["id"]
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid user ID")
 		return
 	}
 
-	user, err := uc.UserService.GetUserByID(id)
+	user, err := uc.UserService.GetUserByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		problems.WriteError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(user)
+	setETag(w, user.Version)
+	utils.WriteJSON(w, r, user)
 }
 
 func (uc *UserController) UpdateUser(w http.ResponseWriter, r *http.Request, req *services.UserRequest) {
@@ -42,17 +44,18 @@ func (uc *UserController) UpdateUser(w http.ResponseWriter, r *http.Request, req
 	idStr := vars["id"]
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid user ID")
 		return
 	}
 
-	user, err := uc.UserService.UpdateUser(id, req)
+	user, err := uc.UserService.UpdateUser(r.Context(), id, req)
 	if err != nil {
-		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(user)
+	setETag(w, user.Version)
+	utils.WriteJSON(w, r, user)
 }
 
 func (uc *UserController) UpdateUserPassword(w http.ResponseWriter, r *http.Request, req *services.UserRequest) {
@@ -60,17 +63,18 @@ func (uc *UserController) UpdateUserPassword(w http.ResponseWriter, r *http.Requ
 	idStr := vars["id"]
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid user ID")
 		return
 	}
 
-	user, err := uc.UserService.UpdateUserPassword(id, req)
+	user, err := uc.UserService.UpdateUserPassword(r.Context(), id, req)
 	if err != nil {
-		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(user)
+	setETag(w, user.Version)
+	utils.WriteJSON(w, r, user)
 }
 
 func (uc *UserController) DeleteUser(w http.ResponseWriter, r *http.Request) {
@@ -78,13 +82,13 @@ func (uc *UserController) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["id"]
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid user ID")
 		return
 	}
 
-	err = uc.UserService.DeleteUser(id)
+	err = uc.UserService.DeleteUser(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		problems.WriteError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
+)
+
+func Open(ctx context.Context, cfg config.DatabaseConfig, logger *slog.Logger) (*sql.DB, error) {
+	db, err := newPool(cfg, cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	backoff := Backoff{Initial: cfg.ConnectBackoff, Max: cfg.ConnectMaxBackoff}
+	if err := Retry(ctx, backoff, logger, db.PingContext); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
+
+func OpenReplica(cfg config.DatabaseConfig) (*sql.DB, error) {
+	return newPool(cfg, cfg.ReplicaURL)
+}
+
+func newPool(cfg config.DatabaseConfig, address string) (*sql.DB, error) {
+	dsn, err := DSN(address, cfg.StatementTimeout)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
+}
+
+func DSN(dsn string, statementTimeout time.Duration) (string, error) {
+	if statementTimeout <= 0 {
+		return dsn, nil
+	}
+	timeout := strconv.FormatInt(statementTimeout.Milliseconds(), 10)
+
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		if strings.Contains(dsn, "statement_timeout=") {
+			return dsn, nil
+		}
+		return strings.TrimSpace(dsn + " statement_timeout=" + timeout), nil
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid database URL: %w", err)
+	}
+	query := u.Query()
+	if query.Get("statement_timeout") == "" {
+		query.Set("statement_timeout", timeout)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Initial
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	return min(delay, b.Max)
+}
+
+func Retry(ctx context.Context, backoff Backoff, logger *slog.Logger, fn func(context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		delay := backoff.Delay(attempt)
+		delay = delay/2 + rand.N(delay/2+1)
+		logger.Warn("database not ready, retrying", "attempt", attempt, "retry_in", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		case <-timer.C:
+		}
+	}
+}
+
+func LogPoolStats(ctx context.Context, db *sql.DB, interval time.Duration, logger *slog.Logger) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := db.Stats()
+			logger.Info("database pool stats",
+				"open", stats.OpenConnections,
+				"in_use", stats.InUse,
+				"idle", stats.Idle,
+				"max_open", stats.MaxOpenConnections,
+				"wait_count", stats.WaitCount,
+				"wait_duration", stats.WaitDuration,
+				"max_idle_closed", stats.MaxIdleClosed,
+				"max_idle_time_closed", stats.MaxIdleTimeClosed,
+				"max_lifetime_closed", stats.MaxLifetimeClosed,
+			)
+		}
+	}
+}
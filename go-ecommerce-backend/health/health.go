@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/migrations"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusDirty       = "dirty"
+)
+
+type CheckResult struct {
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type Checker struct {
+	DB          *sql.DB
+	PingTimeout time.Duration
+	draining    atomic.Bool
+}
+
+func NewChecker(db *sql.DB, pingTimeout time.Duration) *Checker {
+	return &Checker{
+		DB:          db,
+		PingTimeout: pingTimeout,
+	}
+}
+
+func (c *Checker) SetDraining() {
+	c.draining.Store(true)
+}
+
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+func (c *Checker) Readiness(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: map[string]CheckResult{}}
+
+	if c.Draining() {
+		report.Checks["shutdown"] = CheckResult{Status: StatusUnavailable, Error: "server is draining"}
+	} else {
+		report.Checks["shutdown"] = CheckResult{Status: StatusOK}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.PingTimeout)
+	defer cancel()
+
+	if c.DB == nil {
+		report.Checks["database"] = CheckResult{Status: StatusOK, Details: map[string]any{"storage": "memory"}}
+	} else {
+		report.Checks["database"] = c.checkDatabase(ctx)
+		report.Checks["migrations"] = c.checkMigrations(ctx)
+	}
+
+	for _, check := range report.Checks {
+		if check.Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+func (c *Checker) checkDatabase(ctx context.Context) CheckResult {
+	start := time.Now()
+	if err := c.DB.PingContext(ctx); err != nil {
+		slog.WarnContext(ctx, "readiness database check failed", "error", err)
+		return CheckResult{Status: StatusUnavailable}
+	}
+	return CheckResult{Status: StatusOK, Details: map[string]any{"latency_ms": time.Since(start).Milliseconds()}}
+}
+
+func (c *Checker) checkMigrations(ctx context.Context) CheckResult {
+	expected, err := migrations.LatestVersion()
+	if err != nil {
+		slog.ErrorContext(ctx, "readiness migrations check failed", "error", err)
+		return CheckResult{Status: StatusUnavailable}
+	}
+
+	version, dirty, err := migrations.SchemaVersion(ctx, c.DB)
+	if err != nil {
+		slog.WarnContext(ctx, "readiness migrations check failed", "error", err)
+		return CheckResult{Status: StatusUnavailable}
+	}
+
+	result := CheckResult{
+		Status:  StatusOK,
+		Details: map[string]any{"version": version, "expected": expected, "dirty": dirty},
+	}
+	switch {
+	case dirty:
+		result.Status = StatusDirty
+	case version != expected:
+		result.Status = StatusUnavailable
+		result.Error = fmt.Sprintf("schema version %d does not match expected version %d", version, expected)
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package integration_tests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+)
+
+func createOrderPerItem(ctx context.Context, db *sql.DB, order *models.Order) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO orders (customer_id, status) VALUES ($1, $2) RETURNING id", order.CustomerID, order.Status).Scan(&order.ID)
+	if err != nil {
+		return err
+	}
+	for _, item := range order.OrderItems {
+		var available int
+		var price float64
+		if err = tx.QueryRowContext(ctx, "SELECT stock, price FROM products WHERE id = $1 FOR UPDATE", item.ProductID).Scan(&available, &price); err != nil {
+			return err
+		}
+		if available < item.Quantity {
+			return &apperrors.InsufficientStockError{ProductID: item.ProductID, Available: available, Requested: item.Quantity}
+		}
+		if _, err = tx.ExecContext(ctx, "UPDATE products SET stock = stock - $1, version = version + 1 WHERE id = $2", item.Quantity, item.ProductID); err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, "INSERT INTO order_items (order_id, product_id, quantity, unit_price) VALUES ($1, $2, $3, $4)", order.ID, item.ProductID, item.Quantity, price); err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE orders SET total = (SELECT SUM(quantity * unit_price) FROM order_items WHERE order_id = $1) WHERE id = $1", order.ID)
+	return err
+}
+
+func BenchmarkOrderCreate(b *testing.B) {
+	repos, db := newTestRepositories(b)
+	f := newFactory(b, repos)
+	customer := f.Customer()
+
+	for _, size := range []int{1, 10, 50} {
+		items := make([]models.OrderItem, size)
+		for i := range items {
+			items[i] = models.OrderItem{ProductID: f.Product(withStock(1_000_000_000)).ID, Quantity: 1}
+		}
+		newOrder := func() *models.Order {
+			return &models.Order{CustomerID: customer.ID, Status: models.OrderStatusPending, OrderItems: items}
+		}
+
+		b.Run(fmt.Sprintf("per-item/items=%d", size), func(b *testing.B) {
+			for b.Loop() {
+				if err := createOrderPerItem(context.Background(), db, newOrder()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("set-based/items=%d", size), func(b *testing.B) {
+			for b.Loop() {
+				if err := repos.Orders.Create(context.Background(), newOrder()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
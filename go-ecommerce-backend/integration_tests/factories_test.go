@@ -0,0 +1,140 @@
+package integration_tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/seed"
+)
+
+type factory struct {
+	t     testing.TB
+	repos *repositories.Repositories
+	seq   int
+}
+
+func newFactory(t testing.TB, repos *repositories.Repositories) *factory {
+	return &factory{t: t, repos: repos}
+}
+
+func (f *factory) next() int {
+	f.seq++
+	return f.seq
+}
+
+func (f *factory) User(opts ...func(*models.User)) *models.User {
+	f.t.Helper()
+	user := &models.User{Email: fmt.Sprintf("user%d@example.com", f.next()), Password: "hash", Role: models.RoleCustomer}
+	for _, opt := range opts {
+		opt(user)
+	}
+	if err := f.repos.Users.Create(context.Background(), user); err != nil {
+		f.t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func (f *factory) Customer(opts ...func(*models.Customer)) *models.Customer {
+	f.t.Helper()
+	n := f.next()
+	customer := &models.Customer{FirstName: "First", LastName: fmt.Sprintf("Last%d", n), PhoneNumber: fmt.Sprintf("555-%04d", n)}
+	for _, opt := range opts {
+		opt(customer)
+	}
+	if customer.UserID == 0 {
+		customer.UserID = f.User().ID
+	}
+	if err := f.repos.Customers.Create(context.Background(), customer); err != nil {
+		f.t.Fatalf("create customer: %v", err)
+	}
+	return customer
+}
+
+func (f *factory) Address(opts ...func(*models.Address)) *models.Address {
+	f.t.Helper()
+	address := &models.Address{StreetAddress: fmt.Sprintf("%d Main St", f.next()), City: "London", Country: "UK"}
+	for _, opt := range opts {
+		opt(address)
+	}
+	if address.CustomerID == 0 {
+		address.CustomerID = f.Customer().ID
+	}
+	if err := f.repos.Addresses.Create(context.Background(), address); err != nil {
+		f.t.Fatalf("create address: %v", err)
+	}
+	return address
+}
+
+func (f *factory) Product(opts ...func(*models.Product)) *models.Product {
+	f.t.Helper()
+	n := f.next()
+	product := &models.Product{Name: fmt.Sprintf("Product %d", n), Description: "Test product", Category: "test", Price: 9.99, Stock: 10}
+	for _, opt := range opts {
+		opt(product)
+	}
+	if err := f.repos.Products.Create(context.Background(), product); err != nil {
+		f.t.Fatalf("create product: %v", err)
+	}
+	return product
+}
+
+func (f *factory) Order(opts ...func(*models.Order)) *models.Order {
+	f.t.Helper()
+	order := &models.Order{Status: models.OrderStatusPending}
+	for _, opt := range opts {
+		opt(order)
+	}
+	if order.CustomerID == 0 {
+		order.CustomerID = f.Customer().ID
+	}
+	if order.OrderItems == nil {
+		order.OrderItems = []models.OrderItem{{ProductID: f.Product().ID, Quantity: 1}}
+	}
+	if err := f.repos.Orders.Create(context.Background(), order); err != nil {
+		f.t.Fatalf("create order: %v", err)
+	}
+	return order
+}
+
+func (f *factory) IdempotencyRecord(opts ...func(*models.IdempotencyRecord)) *models.IdempotencyRecord {
+	f.t.Helper()
+	n := f.next()
+	record := &models.IdempotencyRecord{UserID: n, Key: fmt.Sprintf("key-%d", n), Fingerprint: strings.Repeat("0", 64)}
+	for _, opt := range opts {
+		opt(record)
+	}
+	if _, err := f.repos.Idempotency.Reserve(context.Background(), record, time.Hour, time.Minute); err != nil {
+		f.t.Fatalf("reserve idempotency key: %v", err)
+	}
+	return record
+}
+
+func withStock(stock int) func(*models.Product) {
+	return func(p *models.Product) { p.Stock = stock }
+}
+
+func withCustomer(customerID int) func(*models.Order) {
+	return func(o *models.Order) { o.CustomerID = customerID }
+}
+
+func withItems(items ...models.OrderItem) func(*models.Order) {
+	return func(o *models.Order) { o.OrderItems = items }
+}
+
+func loadFixtures(t *testing.T, repos *repositories.Repositories) *seed.Summary {
+	t.Helper()
+	fixtures, err := seed.DefaultFixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := seed.NewSeeder(repos).Load(context.Background(), fixtures)
+	if err != nil {
+		t.Fatalf("load fixtures: %v", err)
+	}
+	return summary
+}
@@ -0,0 +1,117 @@
+package integration_tests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/migrations"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+)
+
+var harness struct {
+	url      *url.URL
+	template string
+	admin    *sql.DB
+	mu       sync.Mutex
+	clones   atomic.Int64
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	if dsn := os.Getenv("DB_TEST"); dsn != "" {
+		if err := setupTemplate(dsn); err != nil {
+			fmt.Fprintln(os.Stderr, "integration tests:", err)
+			return 1
+		}
+		defer harness.admin.Close()
+	}
+	return m.Run()
+}
+
+func setupTemplate(dsn string) error {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return fmt.Errorf("DB_TEST must be a postgres:// URL")
+	}
+	template := strings.TrimPrefix(u.Path, "/")
+	if template == "" {
+		return fmt.Errorf("DB_TEST must name a database")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	err = migrations.RunMigrations(context.Background(), db, template)
+	db.Close()
+	if err != nil {
+		return err
+	}
+
+	admin, err := sql.Open("postgres", withDatabase(u, "postgres"))
+	if err != nil {
+		return err
+	}
+	if err := admin.Ping(); err != nil {
+		admin.Close()
+		return fmt.Errorf("failed to connect to the postgres maintenance database: %w", err)
+	}
+
+	harness.url, harness.template, harness.admin = u, template, admin
+	return nil
+}
+
+func withDatabase(u *url.URL, name string) string {
+	clone := *u
+	clone.Path = "/" + name
+	return clone.String()
+}
+
+func newTestDB(t testing.TB) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("postgres", newTestDatabaseURL(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newTestDatabaseURL(t testing.TB) string {
+	t.Helper()
+	if harness.admin == nil {
+		t.Skip("DB_TEST is not set")
+	}
+
+	name := fmt.Sprintf("%s_%d_%d", harness.template, os.Getpid(), harness.clones.Add(1))
+	harness.mu.Lock()
+	_, err := harness.admin.Exec("CREATE DATABASE " + pq.QuoteIdentifier(name) + " TEMPLATE " + pq.QuoteIdentifier(harness.template))
+	harness.mu.Unlock()
+	if err != nil {
+		t.Fatalf("failed to clone %s: %v", harness.template, err)
+	}
+
+	t.Cleanup(func() {
+		if _, err := harness.admin.Exec("DROP DATABASE IF EXISTS " + pq.QuoteIdentifier(name) + " WITH (FORCE)"); err != nil {
+			t.Errorf("failed to drop %s: %v", name, err)
+		}
+	})
+	return withDatabase(harness.url, name)
+}
+
+func newTestRepositories(t testing.TB) (*repositories.Repositories, *sql.DB) {
+	db := newTestDB(t)
+	return repositories.NewSQLRepositories(repositories.PrimaryOnly(db), repositories.DefaultTimeouts), db
+}
@@ -0,0 +1,53 @@
+package integration_tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/cli"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/migrations"
+)
+
+func TestMigrations_KeepThePoolOpen(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	var name string
+	if err := db.QueryRowContext(ctx, "SELECT current_database()").Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+
+	if !assert.NoError(t, migrations.StepDown(ctx, db, name, 1)) {
+		return
+	}
+	assert.NoError(t, db.PingContext(ctx), "Rolling back should not close the pool")
+
+	if !assert.NoError(t, migrations.RunMigrations(ctx, db, name)) {
+		return
+	}
+	assert.NoError(t, db.PingContext(ctx), "Migrating should not close the pool")
+
+	version, dirty, err := migrations.SchemaVersion(ctx, db)
+	latest, latestErr := migrations.LatestVersion()
+	if assert.NoError(t, err) && assert.NoError(t, latestErr) {
+		assert.Equal(t, latest, version)
+		assert.False(t, dirty)
+	}
+}
+
+func TestCLI_MigrateUpPrintsStatus(t *testing.T) {
+	t.Setenv("DB_PROD", newTestDatabaseURL(t))
+	t.Setenv("JWT_SECRET", "test-secret")
+	ctx := context.Background()
+
+	for _, args := range [][]string{{"migrate", "down", "1"}, {"migrate", "up"}} {
+		var stdout, stderr bytes.Buffer
+		err := cli.Run(ctx, append([]string{"--env-file", ""}, args...), &stdout, &stderr)
+		if !assert.NoError(t, err, "migrate %v: %s", args, stderr.String()) {
+			return
+		}
+		assert.Contains(t, stdout.String(), "dirty: false", "migrate %v should print the status after migrating", args)
+	}
+}
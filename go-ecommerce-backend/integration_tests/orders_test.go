@@ -0,0 +1,113 @@
+package integration_tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+)
+
+func placeConcurrently(repos *repositories.Repositories, orders []*models.Order) []error {
+	errs := make([]error, len(orders))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, order := range orders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = repos.Orders.Create(context.Background(), order)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+func TestOrders_ConcurrentOrdersOnScarceStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		stock     int
+		buyers    int
+		quantity  int
+		succeeded int
+	}{
+		{"single units", 5, 20, 1, 5},
+		{"multiple units", 10, 8, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, db := newTestRepositories(t)
+			f := newFactory(t, repos)
+			product := f.Product(withStock(tt.stock))
+
+			orders := make([]*models.Order, tt.buyers)
+			for i := range orders {
+				orders[i] = &models.Order{
+					CustomerID: f.Customer().ID,
+					Status:     models.OrderStatusPending,
+					OrderItems: []models.OrderItem{{ProductID: product.ID, Quantity: tt.quantity}},
+				}
+			}
+
+			succeeded := 0
+			for _, err := range placeConcurrently(repos, orders) {
+				switch {
+				case err == nil:
+					succeeded++
+				case !errors.Is(err, apperrors.ErrInsufficientStock):
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			assert.Equal(t, tt.succeeded, succeeded)
+
+			stored, err := repos.Products.GetByID(context.Background(), product.ID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.stock-tt.succeeded*tt.quantity, stored.Stock, "Stock should never be oversold")
+			}
+
+			var orderCount, reserved int
+			err = db.QueryRow("SELECT COUNT(*), COALESCE(SUM(quantity), 0) FROM order_items WHERE product_id = $1", product.ID).Scan(&orderCount, &reserved)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.succeeded, orderCount)
+			assert.Equal(t, tt.succeeded*tt.quantity, reserved)
+
+			err = db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&orderCount)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.succeeded, orderCount, "Rejected orders should be rolled back")
+		})
+	}
+}
+
+func TestOrders_OppositeItemOrderDoesNotDeadlock(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	f := newFactory(t, repos)
+	first := f.Product(withStock(100))
+	second := f.Product(withStock(100))
+	customer := f.Customer()
+
+	orders := make([]*models.Order, 40)
+	for i := range orders {
+		items := []models.OrderItem{{ProductID: first.ID, Quantity: 1}, {ProductID: second.ID, Quantity: 1}}
+		if i%2 == 1 {
+			items[0], items[1] = items[1], items[0]
+		}
+		orders[i] = &models.Order{CustomerID: customer.ID, Status: models.OrderStatusPending, OrderItems: items}
+	}
+
+	for _, err := range placeConcurrently(repos, orders) {
+		assert.NoError(t, err)
+	}
+	for _, product := range []*models.Product{first, second} {
+		stored, err := repos.Products.GetByID(context.Background(), product.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 60, stored.Stock)
+		}
+	}
+}
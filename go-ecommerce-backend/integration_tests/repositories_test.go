@@ -0,0 +1,68 @@
+package integration_tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories/repotest"
+)
+
+func TestRepositoryContract_Postgres(t *testing.T) {
+	repotest.RunContract(t, func(t *testing.T) *repositories.Repositories {
+		repos, _ := newTestRepositories(t)
+		return repos
+	})
+}
+
+func TestHarness_TestsAreIsolated(t *testing.T) {
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			repos, _ := newTestRepositories(t)
+
+			products, err := repos.Products.GetAll(context.Background())
+			assert.NoError(t, err)
+			assert.Empty(t, products, "Each test should start with an empty, migrated database")
+
+			product := newFactory(t, repos).Product()
+			assert.Equal(t, 1, product.ID, "Sequences should not leak between tests")
+		})
+	}
+}
+
+func TestFixtures_Load(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+
+	summary := loadFixtures(t, repos)
+
+	assert.NotZero(t, summary.Products)
+	assert.NotZero(t, summary.Orders)
+	admin, err := repos.Users.GetByEmail(context.Background(), "admin@example.com")
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.RoleAdmin, admin.Role)
+	}
+}
+
+func TestFactories(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	f := newFactory(t, repos)
+
+	address := f.Address()
+	order := f.Order()
+	record := f.IdempotencyRecord()
+
+	owner, err := repos.Addresses.GetOwnerID(context.Background(), address.ID)
+	assert.NoError(t, err)
+	assert.NotZero(t, owner)
+	stored, err := repos.Orders.GetByID(context.Background(), order.ID)
+	if assert.NoError(t, err) {
+		assert.Len(t, stored.OrderItems, 1)
+	}
+	existing, err := repos.Idempotency.Reserve(context.Background(), record, 0, 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, existing)
+}
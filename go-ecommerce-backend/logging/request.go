@@ -0,0 +1,24 @@
+package logging
+
+import "context"
+
+type requestInfoKey struct{}
+
+type RequestInfo struct {
+	UserID int
+}
+
+func ContextWithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+func RequestInfoFromContext(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
+
+func SetUserID(ctx context.Context, userID int) {
+	if info := RequestInfoFromContext(ctx); info != nil {
+		info.UserID = userID
+	}
+}
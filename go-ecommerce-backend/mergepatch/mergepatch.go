@@ -0,0 +1,72 @@
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+const ContentType = "application/merge-patch+json"
+
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+func Apply(doc, patch []byte) ([]byte, error) {
+	var patchValue any
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, err
+	}
+
+	var docValue any
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if err := json.Unmarshal(doc, &docValue); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(merge(docValue, patchValue))
+}
+
+func ApplyTo(target any, patch []byte) error {
+	if !IsObject(patch) {
+		return ErrNotObject
+	}
+
+	doc, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	merged, err := Apply(doc, patch)
+	if err != nil {
+		return err
+	}
+
+	reflect.ValueOf(target).Elem().SetZero()
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	return dec.Decode(target)
+}
+
+func IsObject(patch []byte) bool {
+	var value map[string]json.RawMessage
+	return json.Unmarshal(patch, &value) == nil && value != nil
+}
+
+func merge(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = merge(targetObject[key], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ecommerce"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by route template, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	})
+
+	OrdersCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_created_total",
+		Help:      "Number of orders created.",
+	})
+
+	InsufficientStockRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "order_insufficient_stock_rejections_total",
+		Help:      "Number of orders rejected because of insufficient stock.",
+	})
+
+	LoginFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Number of failed login attempts.",
+	})
+
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Number of requests rejected by the rate limiter, by policy.",
+	}, []string{"policy"})
+)
+
+func RegisterDBStats(db *sql.DB, dbName string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/logging"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
 )
 
@@ -15,25 +17,36 @@ const (
 	ContextUserRole contextKey = "userRole"
 )
 
-func JWTAuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+func JWTAuthMiddleware(jwt utils.JWTOptions) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Missing token", http.StatusUnauthorized)
+				problems.Write(w, r, http.StatusUnauthorized, problems.CodeMissingToken, "Missing token")
 				return
 			}
 
 			tokenStr := strings.Replace(authHeader, "Bearer ", "", 1)
-			claims, err := utils.ValidateJWT(tokenStr, jwtSecret)
+			claims, err := utils.ValidateJWT(tokenStr, jwt)
 			if err != nil {
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				problems.Write(w, r, http.StatusUnauthorized, problems.CodeInvalidToken, "Invalid token")
 				return
 			}
 
+			logging.SetUserID(r.Context(), claims.UserID)
+
 			ctx := context.WithValue(r.Context(), ContextUserID, claims.UserID)
 			ctx = context.WithValue(ctx, ContextUserRole, claims.Role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+func bearerClaims(r *http.Request, jwt utils.JWTOptions) (*utils.Claims, bool) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return nil, false
+	}
+	claims, err := utils.ValidateJWT(token, jwt)
+	return claims, err == nil
+}
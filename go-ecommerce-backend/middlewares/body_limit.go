@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+)
+
+func BodyLimitMiddleware(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if maxBytes <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > maxBytes {
+				writeBodyTooLarge(w, r, maxBytes)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, r *http.Request, maxBytes int64) {
+	problems.Write(w, r, http.StatusRequestEntityTooLarge, problems.CodeBodyTooLarge, "Request body must not exceed "+strconv.FormatInt(maxBytes, 10)+" bytes")
+}
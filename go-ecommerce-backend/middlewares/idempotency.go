@@ -0,0 +1,148 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	MaxIdempotencyKeyLength  = 255
+)
+
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+func IdempotencyMiddleware(repo repositories.IdempotencyRepository, ttl, lease time.Duration) func(http.HandlerFunc) http.HandlerFunc {
+	return func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			userID, authenticated := r.Context().Value(ContextUserID).(int)
+			if key == "" || !authenticated {
+				handler(w, r)
+				return
+			}
+			if len(key) > MaxIdempotencyKeyLength {
+				problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidIdempotencyKey, fmt.Sprintf("Idempotency-Key must be at most %d characters", MaxIdempotencyKeyLength))
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeDecodeError(w, r, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			record := &models.IdempotencyRecord{
+				UserID:      userID,
+				Key:         key,
+				Fingerprint: fingerprint(r, body),
+			}
+
+			existing, err := repo.Reserve(r.Context(), record, ttl, lease)
+			if err != nil {
+				problems.WriteError(w, r, err)
+				return
+			}
+			if existing != nil {
+				replay(w, r, record, existing)
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w}
+			release := true
+			defer func() {
+				if !release {
+					return
+				}
+				if err := repo.Release(context.WithoutCancel(r.Context()), record); err != nil {
+					slog.ErrorContext(r.Context(), "failed to release idempotency key", "error", err)
+				}
+			}()
+
+			handler(rec, r)
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+			release = false
+
+			record.Status = rec.status
+			record.Body = rec.body.Bytes()
+			record.Headers = http.Header{}
+			for _, name := range replayedHeaders {
+				if value := w.Header().Get(name); value != "" {
+					record.Headers.Set(name, value)
+				}
+			}
+			if err := repo.Complete(context.WithoutCancel(r.Context()), record); err != nil {
+				slog.ErrorContext(r.Context(), "failed to store idempotent response, keeping the key reserved until its lease expires", "error", err)
+			}
+		}
+	}
+}
+
+func replay(w http.ResponseWriter, r *http.Request, record, existing *models.IdempotencyRecord) {
+	if existing.Fingerprint != record.Fingerprint {
+		problems.Write(w, r, http.StatusUnprocessableEntity, problems.CodeIdempotencyKeyReused, "Idempotency-Key was already used with a different request")
+		return
+	}
+	if !existing.Completed() {
+		problems.Write(w, r, http.StatusConflict, problems.CodeRequestInProgress, "A request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	for name, values := range existing.Headers {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(existing.Status)
+	w.Write(existing.Body)
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/mergepatch"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+)
+
+func MergePatchBody(handler func(w http.ResponseWriter, r *http.Request, patch []byte)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != mergepatch.ContentType {
+			w.Header().Set("Accept-Patch", mergepatch.ContentType)
+			problems.Write(w, r, http.StatusUnsupportedMediaType, problems.CodeUnsupportedMediaType, "PATCH requests must use "+mergepatch.ContentType)
+			return
+		}
+
+		patch, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeDecodeError(w, r, err)
+			return
+		}
+		if !mergepatch.IsObject(patch) {
+			problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidBody, "Merge patch must be a JSON object")
+			return
+		}
+		handler(w, r, patch)
+	}
+}
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/metrics"
+)
+
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(routeTemplate(r), r.Method, strconv.Itoa(rec.status)).
+			Observe(time.Since(start).Seconds())
+	})
+}
@@ -1,44 +1,46 @@
 package middlewares
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 )
 
-type OwnerVerifierFunc func(resourceID int) (int, error)
+type OwnerVerifierFunc func(ctx context.Context, resourceID int) (int, error)
 
 func OwnerOnlyMiddleware(paramName string, getOwnerID OwnerVerifierFunc) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			userID, ok := r.Context().Value(ContextUserID).(int)
 			if !ok {
-				http.Error(w, "User not authenticated", http.StatusUnauthorized)
+				problems.Write(w, r, http.StatusUnauthorized, problems.CodeUnauthenticated, "User not authenticated")
 				return
 			}
 
 			vars := mux.Vars(r)
 			resourceIDStr, exists := vars[paramName]
 			if !exists {
-				http.Error(w, "Resource identifier not provided", http.StatusBadRequest)
+				problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Resource identifier not provided")
 				return
 			}
 
 			resourceID, err := strconv.Atoi(resourceIDStr)
 			if err != nil {
-				http.Error(w, "Invalid resource identifier", http.StatusBadRequest)
+				problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidID, "Invalid resource identifier")
 				return
 			}
 
-			ownerID, err := getOwnerID(resourceID)
+			ownerID, err := getOwnerID(r.Context(), resourceID)
 			if err != nil {
-				http.Error(w, "Resource not found", http.StatusNotFound)
+				problems.WriteError(w, r, err)
 				return
 			}
 
 			if userID != ownerID {
-				http.Error(w, "Not authorized to access this resource", http.StatusForbidden)
+				problems.Write(w, r, http.StatusForbidden, problems.CodeForbidden, "Not authorized to access this resource")
 				return
 			}
 
@@ -0,0 +1,98 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/preconditions"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+)
+
+func IfMatchMiddleware(required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := strings.TrimSpace(r.Header.Get("If-Match"))
+			if header == "" {
+				if required {
+					problems.Write(w, r, http.StatusPreconditionRequired, problems.CodePreconditionRequired, "If-Match header is required")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			versions, wildcard, ok := preconditions.ParseIfMatch(header)
+			if !ok {
+				problems.Write(w, r, http.StatusPreconditionFailed, problems.CodePreconditionFailed, "If-Match does not contain a valid entity tag")
+				return
+			}
+			if wildcard {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(versions) == 0 {
+				problems.Write(w, r, http.StatusPreconditionFailed, problems.CodePreconditionFailed, "If-Match requires a strong entity tag")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(preconditions.ContextWithExpectedVersions(r.Context(), versions)))
+		})
+	}
+}
+
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.header
+}
+
+func (br *bufferedResponse) WriteHeader(status int) {
+	if br.status == 0 {
+		br.status = status
+	}
+}
+
+func (br *bufferedResponse) Write(b []byte) (int, error) {
+	if br.status == 0 {
+		br.status = http.StatusOK
+	}
+	return br.body.Write(b)
+}
+
+func ConditionalGet(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		buf := &bufferedResponse{header: w.Header()}
+		handler(buf, r)
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+
+		if buf.status == http.StatusOK {
+			etag := w.Header().Get("ETag")
+			if etag == "" {
+				sum := sha256.Sum256(buf.body.Bytes())
+				etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+				w.Header().Set("ETag", etag)
+			}
+			if match := r.Header.Get("If-None-Match"); match != "" && preconditions.Matches(match, etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		w.WriteHeader(buf.status)
+		w.Write(buf.body.Bytes())
+	}
+}
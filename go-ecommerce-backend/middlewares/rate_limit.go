@@ -0,0 +1,94 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/metrics"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/ratelimit"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+func RateLimitMiddleware(cfg ratelimit.Config, store ratelimit.Store, jwt utils.JWTOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			policy, ok := cfg.PolicyFor(r.Method, routeTemplate(r))
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := policy.Name + "|" + clientKey(r, policy.Key, cfg.TrustedProxies, jwt)
+			res, err := store.Take(r.Context(), key, policy)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "rate limiter unavailable, allowing request", "policy", policy.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(res.Reset.Seconds())))
+			w.Header().Set("RateLimit-Policy", strconv.Itoa(policy.Limit)+";w="+strconv.Itoa(int(policy.Window.Seconds())))
+
+			if !res.Allowed {
+				metrics.RateLimitRejections.WithLabelValues(policy.Name).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(res.RetryAfter.Seconds())))
+				problems.Write(w, r, http.StatusTooManyRequests, problems.CodeRateLimited, "Rate limit exceeded, retry later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientKey(r *http.Request, kind ratelimit.KeyKind, proxies ratelimit.TrustedProxies, jwt utils.JWTOptions) string {
+	switch kind {
+	case ratelimit.KeyUser:
+		if claims, ok := bearerClaims(r, jwt); ok {
+			return "user:" + strconv.Itoa(claims.UserID)
+		}
+	case ratelimit.KeyAPIKey:
+		if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			return "api_key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + clientIP(r, proxies)
+}
+
+func clientIP(r *http.Request, proxies ratelimit.TrustedProxies) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if addr, err := netip.ParseAddr(host); err != nil || !proxies.Contains(addr) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(hop)
+		if err != nil {
+			return host
+		}
+		if !proxies.Contains(addr) {
+			return addr.Unmap().String()
+		}
+		host = hop
+	}
+	return host
+}
@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+const ReadYourWritesCookie = "read_your_writes"
+
+type writeMarker struct {
+	http.ResponseWriter
+	mark   func()
+	marked bool
+}
+
+func (wm *writeMarker) WriteHeader(status int) {
+	if !wm.marked {
+		wm.marked = true
+		if status < http.StatusBadRequest {
+			wm.mark()
+		}
+	}
+	wm.ResponseWriter.WriteHeader(status)
+}
+
+func (wm *writeMarker) Write(b []byte) (int, error) {
+	if !wm.marked {
+		wm.WriteHeader(http.StatusOK)
+	}
+	return wm.ResponseWriter.Write(b)
+}
+
+func (wm *writeMarker) Unwrap() http.ResponseWriter {
+	return wm.ResponseWriter
+}
+
+func ReadYourWritesMiddleware(cluster *repositories.Cluster, jwt utils.JWTOptions) func(http.Handler) http.Handler {
+	stickiness := cluster.Stickiness()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := 0
+			if claims, ok := bearerClaims(r, jwt); ok {
+				userID = claims.UserID
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				if userID != 0 && recentlyWrote(r, userID, jwt.Secret) {
+					r = r.WithContext(repositories.WithPrimary(r.Context()))
+				}
+				next.ServeHTTP(w, r)
+			default:
+				if userID != 0 && stickiness > 0 {
+					w = &writeMarker{ResponseWriter: w, mark: func() {
+						http.SetCookie(w, &http.Cookie{
+							Name:     ReadYourWritesCookie,
+							Value:    signPrimaryUntil(userID, time.Now().Add(stickiness), jwt.Secret),
+							Path:     "/",
+							MaxAge:   int(stickiness.Seconds()) + 1,
+							HttpOnly: true,
+							Secure:   r.TLS != nil,
+							SameSite: http.SameSiteLaxMode,
+						})
+					}}
+				}
+				next.ServeHTTP(w, r.WithContext(repositories.WithPrimary(r.Context())))
+			}
+		})
+	}
+}
+
+func recentlyWrote(r *http.Request, userID int, secret string) bool {
+	cookie, err := r.Cookie(ReadYourWritesCookie)
+	if err != nil {
+		return false
+	}
+	until, _, found := strings.Cut(cookie.Value, ".")
+	if !found {
+		return false
+	}
+	millis, err := strconv.ParseInt(until, 10, 64)
+	if err != nil || time.Now().After(time.UnixMilli(millis)) {
+		return false
+	}
+	return hmac.Equal([]byte(cookie.Value), []byte(signPrimaryUntil(userID, time.UnixMilli(millis), secret)))
+}
+
+func signPrimaryUntil(userID int, until time.Time, secret string) string {
+	expiry := strconv.FormatInt(until.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("read-your-writes|" + strconv.Itoa(userID) + "|" + expiry))
+	return expiry + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = utils.NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(utils.ContextWithRequestID(r.Context(), requestID)))
+	})
+}
@@ -3,6 +3,8 @@ package middlewares
 import (
 	"net/http"
 	"slices"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 )
 
 func RoleAuthorizationMiddleware(allowedRoles ...string) func(http.Handler) http.Handler {
@@ -10,14 +12,14 @@ func RoleAuthorizationMiddleware(allowedRoles ...string) func(http.Handler) http
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			role, ok := r.Context().Value(ContextUserRole).(string)
 			if !ok {
-				http.Error(w, "User role not found", http.StatusUnauthorized)
+				problems.Write(w, r, http.StatusUnauthorized, problems.CodeUnauthenticated, "User role not found")
 				return
 			}
 			if slices.Contains(allowedRoles, role) {
 				next.ServeHTTP(w, r)
 				return
 			}
-			http.Error(w, "Forbidden", http.StatusForbidden)
+			problems.Write(w, r, http.StatusForbidden, problems.CodeForbidden, "Forbidden")
 		})
 	}
 }
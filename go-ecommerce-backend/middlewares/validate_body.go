@@ -2,22 +2,66 @@ package middlewares
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
 
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
 )
 
 func ValidateBody[T any](handler func(w http.ResponseWriter, r *http.Request, body *T)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			problems.Write(w, r, http.StatusUnsupportedMediaType, problems.CodeUnsupportedMediaType, "Request body must be application/json")
+			return
+		}
+
 		var body T
-		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+		if err := decodeStrict(r.Body, &body); err != nil {
+			writeDecodeError(w, r, err)
 			return
 		}
 		if err := utils.ValidateStruct(body); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			problems.WriteValidation(w, r, err)
 			return
 		}
 		handler(w, r, &body)
 	}
 }
+
+var errTrailingData = errors.New("request body must contain a single JSON value")
+
+func decodeStrict(body io.Reader, dst any) error {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return errTrailingData
+	}
+	return nil
+}
+
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	decoded := apperrors.DecodeError(err)
+	switch {
+	case errors.As(err, &maxBytesErr):
+		writeBodyTooLarge(w, r, maxBytesErr.Limit)
+	case errors.Is(err, errTrailingData):
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeTrailingData, errTrailingData.Error())
+	case errors.As(decoded, new(*apperrors.UnknownFieldError)):
+		problems.FromError(r, decoded).Write(w, r)
+	default:
+		problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidBody, "Invalid request body")
+	}
+}
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS orders_customer_id_created_at_idx;
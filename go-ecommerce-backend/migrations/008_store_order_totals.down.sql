@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_total_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS total;
+ALTER TABLE order_items DROP COLUMN IF EXISTS unit_price;
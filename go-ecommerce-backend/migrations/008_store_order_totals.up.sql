@@ -0,0 +1,10 @@
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS unit_price NUMERIC(10,2);
+UPDATE order_items oi SET unit_price = p.price FROM products p WHERE p.id = oi.product_id AND oi.unit_price IS NULL;
+ALTER TABLE order_items ALTER COLUMN unit_price SET NOT NULL;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total NUMERIC(12,2) NOT NULL DEFAULT 0;
+UPDATE orders o SET total = t.total
+    FROM (SELECT order_id, SUM(quantity * unit_price) AS total FROM order_items GROUP BY order_id) t
+    WHERE t.order_id = o.id;
+
+CREATE INDEX IF NOT EXISTS orders_total_idx ON orders (total, id);
@@ -0,0 +1,2 @@
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+UPDATE idempotency_keys SET locked_until = created_at WHERE status IS NULL;
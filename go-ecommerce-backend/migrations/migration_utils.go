@@ -1,57 +1,134 @@
 package migrations
 
 import (
+	"context"
 	"database/sql"
+	"embed"
+	"errors"
 	"fmt"
-
-	_ "github.com/lib/pq"
+	"io/fs"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/lib/pq"
 )
 
-func RunMigrations(db *sql.DB, databaseName, migrationsPath string) error {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+//go:embed *.sql
+var files embed.FS
+
+func newMigrate(ctx context.Context, db *sql.DB, databaseName string) (*migrate.Migrate, error) {
+	source, err := iofs.New(files, ".")
 	if err != nil {
-		return fmt.Errorf("could not create postgres driver: %w", err)
+		return nil, fmt.Errorf("could not read embedded migrations: %w", err)
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		migrationsPath,
-		databaseName,
-		driver,
-	)
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("could not create migrate instance: %w", err)
+		return nil, fmt.Errorf("could not get a database connection: %w", err)
 	}
-
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to run up migrations: %w", err)
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{DatabaseName: databaseName})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not create postgres driver: %w", err)
 	}
 
-	return nil
+	m, err := migrate.NewWithInstance("iofs", source, databaseName, driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("could not create migrate instance: %w", err)
+	}
+	return m, nil
 }
 
-func RollbackMigrations(db *sql.DB, databaseName, migrationsPath string) error {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+func withMigrate(ctx context.Context, db *sql.DB, databaseName string, fn func(m *migrate.Migrate) error) error {
+	m, err := newMigrate(ctx, db, databaseName)
 	if err != nil {
-		return fmt.Errorf("could not create postgres driver: %w", err)
+		return err
 	}
+	defer m.Close()
+	return fn(m)
+}
+
+func RunMigrations(ctx context.Context, db *sql.DB, databaseName string) error {
+	return withMigrate(ctx, db, databaseName, func(m *migrate.Migrate) error {
+		if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+			return fmt.Errorf("failed to run up migrations: %w", err)
+		}
+		return nil
+	})
+}
+
+func RollbackMigrations(ctx context.Context, db *sql.DB, databaseName string) error {
+	return withMigrate(ctx, db, databaseName, func(m *migrate.Migrate) error {
+		if err := m.Down(); err != nil && err != migrate.ErrNoChange {
+			return fmt.Errorf("failed to run down migrations: %w", err)
+		}
+		return nil
+	})
+}
+
+func StepDown(ctx context.Context, db *sql.DB, databaseName string, steps int) error {
+	return withMigrate(ctx, db, databaseName, func(m *migrate.Migrate) error {
+		if err := m.Steps(-steps); err != nil && err != migrate.ErrNoChange {
+			return fmt.Errorf("failed to roll back %d migrations: %w", steps, err)
+		}
+		return nil
+	})
+}
+
+func Goto(ctx context.Context, db *sql.DB, databaseName string, version uint) error {
+	return withMigrate(ctx, db, databaseName, func(m *migrate.Migrate) error {
+		if err := m.Migrate(version); err != nil && err != migrate.ErrNoChange {
+			return fmt.Errorf("failed to migrate to version %d: %w", version, err)
+		}
+		return nil
+	})
+}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		migrationsPath,
-		databaseName,
-		driver,
-	)
+func Force(ctx context.Context, db *sql.DB, databaseName string, version int) error {
+	return withMigrate(ctx, db, databaseName, func(m *migrate.Migrate) error {
+		if err := m.Force(version); err != nil {
+			return fmt.Errorf("failed to force version %d: %w", version, err)
+		}
+		return nil
+	})
+}
 
+func LatestVersion() (uint, error) {
+	source, err := iofs.New(files, ".")
 	if err != nil {
-		return fmt.Errorf("could not create migrate instance: %w", err)
+		return 0, fmt.Errorf("could not read embedded migrations: %w", err)
 	}
+	defer source.Close()
 
-	if err := m.Down(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to run down migrations: %w", err)
+	version, err := source.First()
+	if err != nil {
+		return 0, fmt.Errorf("no embedded migrations: %w", err)
+	}
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
 	}
+}
 
-	return nil
+func SchemaVersion(ctx context.Context, db *sql.DB) (version uint, dirty bool, err error) {
+	query := "SELECT version, dirty FROM schema_migrations LIMIT 1"
+	err = db.QueryRowContext(ctx, query).Scan(&version, &dirty)
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, false, nil
+	case errors.As(err, &pqErr) && pqErr.Code.Name() == "undefined_table":
+		return 0, false, nil
+	case err != nil:
+		return 0, false, err
+	}
+	return version, dirty, nil
 }
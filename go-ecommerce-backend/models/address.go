@@ -6,4 +6,5 @@ type Address struct {
 	StreetAddress string `json:"street_address"`
 	City          string `json:"city"`
 	Country       string `json:"country"`
+	Version       int    `json:"version"`
 }
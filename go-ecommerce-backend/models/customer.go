@@ -6,4 +6,5 @@ type Customer struct {
 	FirstName   string `json:"first_name"`
 	LastName    string `json:"last_name"`
 	PhoneNumber string `json:"phone_number"`
+	Version     int    `json:"version"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+type IdempotencyRecord struct {
+	UserID      int
+	Key         string
+	Fingerprint string
+	Status      int
+	Headers     http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+	LockedUntil time.Time
+}
+
+func (r *IdempotencyRecord) Completed() bool {
+	return r.Status != 0
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 type OrderStatus string
 
@@ -11,9 +14,41 @@ const (
 )
 
 type Order struct {
-	ID         int         `json:"id"`
-	CustomerID int         `json:"customer_id"`
-	Status     OrderStatus `json:"status"`
-	CreatedAt  time.Time   `json:"created_at"`
-	OrderItems []OrderItem `json:"order_items"`
+	ID              int           `json:"id"`
+	CustomerID      int           `json:"customer_id"`
+	Status          OrderStatus   `json:"status"`
+	Total           float64       `json:"total"`
+	ShippingAddress *OrderAddress `json:"shipping_address"`
+	BillingAddress  *OrderAddress `json:"billing_address"`
+	CreatedAt       time.Time     `json:"created_at"`
+	OrderItems      []OrderItem   `json:"order_items"`
+	Version         int           `json:"version"`
+}
+
+type OrderSummary struct {
+	ID              int           `json:"id"`
+	CustomerID      int           `json:"customer_id"`
+	Status          OrderStatus   `json:"status"`
+	Total           float64       `json:"total"`
+	ShippingAddress *OrderAddress `json:"shipping_address"`
+	BillingAddress  *OrderAddress `json:"billing_address"`
+	CreatedAt       time.Time     `json:"created_at"`
+	Version         int           `json:"version"`
+}
+
+func (o *Order) Summary() OrderSummary {
+	return OrderSummary{
+		ID:              o.ID,
+		CustomerID:      o.CustomerID,
+		Status:          o.Status,
+		Total:           o.Total,
+		ShippingAddress: o.ShippingAddress,
+		BillingAddress:  o.BillingAddress,
+		CreatedAt:       o.CreatedAt,
+		Version:         o.Version,
+	}
+}
+
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
 }
@@ -0,0 +1,17 @@
+package models
+
+type OrderAddress struct {
+	AddressID     int    `json:"address_id"`
+	StreetAddress string `json:"street_address"`
+	City          string `json:"city"`
+	Country       string `json:"country"`
+}
+
+func (a *Address) Snapshot() *OrderAddress {
+	return &OrderAddress{
+		AddressID:     a.ID,
+		StreetAddress: a.StreetAddress,
+		City:          a.City,
+		Country:       a.Country,
+	}
+}
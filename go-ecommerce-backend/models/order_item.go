@@ -1,8 +1,9 @@
 package models
 
 type OrderItem struct {
-	ID        int `json:"id"`
-	OrderID   int `json:"order_id"`
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ID        int     `json:"id"`
+	OrderID   int     `json:"order_id"`
+	ProductID int     `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
 }
@@ -7,4 +7,5 @@ type Product struct {
 	Category    string  `json:"category"`
 	Price       float64 `json:"price"`
 	Stock       int     `json:"stock"`
+	Version     int     `json:"version"`
 }
@@ -8,4 +8,5 @@ type User struct {
 	Password  string    `json:"-"`
 	Role      Role      `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"`
 }
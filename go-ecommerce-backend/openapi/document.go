@@ -0,0 +1,174 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+)
+
+const Version = "3.1.0"
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*OpOutput `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type OpOutput struct {
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Operation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Tags        []string
+	Secured     bool
+	Request     any
+	RequestType string
+	Response    any
+	ContentType string
+	Status      int
+	Query       []Parameter
+	Headers     []Parameter
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+func NormalizePath(template string) string {
+	return pathParamPattern.ReplaceAllString(template, "{$1}")
+}
+
+func Build(info Info, operations []Operation) *Document {
+	gen := newGenerator()
+	gen.schemaFor(problems.Problem{})
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]map[string]*OpOutput{},
+		Components: Components{
+			Schemas: gen.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	sort.SliceStable(operations, func(i, j int) bool { return operations[i].Path < operations[j].Path })
+	for _, op := range operations {
+		path := NormalizePath(op.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*OpOutput{}
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = gen.operation(op)
+	}
+	return doc
+}
+
+func (g *generator) operation(op Operation) *OpOutput {
+	out := &OpOutput{
+		Summary:   op.Summary,
+		Tags:      op.Tags,
+		Responses: map[string]Response{},
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+		out.Parameters = append(out.Parameters, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "integer"},
+		})
+	}
+	out.Parameters = append(out.Parameters, op.Query...)
+	out.Parameters = append(out.Parameters, op.Headers...)
+
+	if op.Request != nil {
+		contentType := op.RequestType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		out.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{contentType: {Schema: g.schemaFor(op.Request)}},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	if op.Response != nil {
+		contentType := op.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		success.Content = map[string]MediaType{contentType: {Schema: g.schemaFor(op.Response)}}
+	}
+	out.Responses[strconv.Itoa(status)] = success
+	out.Responses["default"] = Response{
+		Description: "Error",
+		Content:     map[string]MediaType{"application/problem+json": {Schema: &Schema{Ref: "#/components/schemas/Problem"}}},
+	}
+
+	if op.Secured {
+		out.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+	return out
+}
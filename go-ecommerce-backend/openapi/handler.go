@@ -0,0 +1,53 @@
+package openapi
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+)
+
+//go:embed swagger.html
+var swaggerPage string
+
+var swaggerTemplate = template.Must(template.New("swagger").Parse(swaggerPage))
+
+//go:generate sh -c "curl -sSfL https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-$(cat swaggerui/VERSION).tgz | tar -xz -C swaggerui --strip-components=1 package/swagger-ui.css package/swagger-ui-bundle.js"
+//go:embed swaggerui
+var swaggerAssets embed.FS
+
+func Handler(doc *Document) http.HandlerFunc {
+	body, err := json.Marshal(doc)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			problems.Write(w, r, http.StatusInternalServerError, problems.CodeInternal, "Failed to encode OpenAPI document")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+func SwaggerUIHandler(specURL, assetsURL string) http.HandlerFunc {
+	var page bytes.Buffer
+	err := swaggerTemplate.Execute(&page, map[string]string{"SpecURL": specURL, "AssetsURL": assetsURL})
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to render Swagger UI page", "error", err)
+			problems.Write(w, r, http.StatusInternalServerError, problems.CodeInternal, "Failed to render Swagger UI")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page.Bytes())
+	}
+}
+
+func SwaggerAssetsHandler() http.Handler {
+	assets, _ := fs.Sub(swaggerAssets, "swaggerui")
+	return http.FileServerFS(assets)
+}
@@ -0,0 +1,211 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schema struct {
+	Ref              string             `json:"$ref,omitempty"`
+	Type             any                `json:"type,omitempty"`
+	Format           string             `json:"format,omitempty"`
+	Description      string             `json:"description,omitempty"`
+	Properties       map[string]*Schema `json:"properties,omitempty"`
+	Required         []string           `json:"required,omitempty"`
+	Items            *Schema            `json:"items,omitempty"`
+	Enum             []any              `json:"enum,omitempty"`
+	Minimum          *float64           `json:"minimum,omitempty"`
+	Maximum          *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength        *int               `json:"minLength,omitempty"`
+	MaxLength        *int               `json:"maxLength,omitempty"`
+	MinItems         *int               `json:"minItems,omitempty"`
+	MaxItems         *int               `json:"maxItems,omitempty"`
+	AdditionalProps  *Schema            `json:"additionalProperties,omitempty"`
+}
+
+type generator struct {
+	schemas map[string]*Schema
+}
+
+func newGenerator() *generator {
+	return &generator{schemas: map[string]*Schema{}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *generator) schemaFor(v any) *Schema {
+	return g.schemaForType(reflect.TypeOf(v))
+}
+
+func (g *generator) schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = &Schema{}
+			*g.schemas[t.Name()] = *g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	case t.Kind() == reflect.Struct:
+		return g.structSchema(t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProps: g.schemaForType(t.Elem())}
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := g.structSchema(field.Type)
+			for k, v := range embedded.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := g.schemaForType(field.Type)
+		if required := applyValidateTag(prop, field.Type, field.Tag.Get("validate")); required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = prop
+	}
+	return schema
+}
+
+func applyValidateTag(schema *Schema, t reflect.Type, tag string) bool {
+	if tag == "" || tag == "-" {
+		return false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+	target := schema
+	if schema.Ref != "" {
+		target = &Schema{}
+	}
+
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "dive":
+			if schema.Items != nil {
+				applyValidateTag(schema.Items, t.Elem(), strings.Join(rules[i+1:], ","))
+			}
+			return required
+		case "email":
+			target.Format = "email"
+		case "url":
+			target.Format = "uri"
+		case "uuid":
+			target.Format = "uuid"
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				target.Enum = append(target.Enum, enumValue(t, value))
+			}
+		case "min", "gte":
+			setBound(target, t, param, true, false)
+		case "max", "lte":
+			setBound(target, t, param, false, false)
+		case "gt":
+			setBound(target, t, param, true, true)
+		case "lt":
+			setBound(target, t, param, false, true)
+		case "len":
+			setBound(target, t, param, true, false)
+			setBound(target, t, param, false, false)
+		}
+	}
+	return required
+}
+
+func setBound(schema *Schema, t reflect.Type, param string, lower, exclusive bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	count := int(value)
+	if exclusive && lower {
+		count++
+	} else if exclusive {
+		count--
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if lower {
+			schema.MinLength = &count
+		} else {
+			schema.MaxLength = &count
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if lower {
+			schema.MinItems = &count
+		} else {
+			schema.MaxItems = &count
+		}
+	default:
+		switch {
+		case lower && exclusive:
+			schema.ExclusiveMinimum = &value
+		case lower:
+			schema.Minimum = &value
+		case exclusive:
+			schema.ExclusiveMaximum = &value
+		default:
+			schema.Maximum = &value
+		}
+	}
+}
+
+func enumValue(t reflect.Type, value string) any {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return value
+}
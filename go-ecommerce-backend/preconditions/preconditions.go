@@ -0,0 +1,65 @@
+package preconditions
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+type expectedVersionsKey struct{}
+
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func ParseETag(tag string) (int, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+func Matches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func ParseIfMatch(header string) (versions []int, wildcard, ok bool) {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		switch {
+		case candidate == "":
+			continue
+		case candidate == "*":
+			wildcard = true
+			continue
+		}
+		version, valid := ParseETag(candidate)
+		if !valid {
+			return nil, false, false
+		}
+		if !strings.HasPrefix(candidate, "W/") {
+			versions = append(versions, version)
+		}
+	}
+	return versions, wildcard, true
+}
+
+func ContextWithExpectedVersions(ctx context.Context, versions []int) context.Context {
+	return context.WithValue(ctx, expectedVersionsKey{}, versions)
+}
+
+func ExpectedVersions(ctx context.Context) ([]int, bool) {
+	versions, ok := ctx.Value(expectedVersionsKey{}).([]int)
+	return versions, ok
+}
@@ -0,0 +1,87 @@
+package problems
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		WriteValidation(w, r, err)
+		return
+	}
+
+	FromError(r, err).Write(w, r)
+}
+
+func FromError(r *http.Request, err error) *Problem {
+	status, code := StatusForError(err)
+	if status >= http.StatusInternalServerError && r != nil && errors.Is(r.Context().Err(), context.Canceled) {
+		status, code = StatusClientClosedRequest, CodeClientClosedRequest
+	}
+	detail := err.Error()
+	switch status {
+	case StatusClientClosedRequest:
+		detail = "Client closed request"
+		if r != nil {
+			slog.InfoContext(r.Context(), "client closed request", "error", err)
+		}
+	case http.StatusServiceUnavailable:
+		detail = "Request timed out"
+		if r != nil {
+			slog.WarnContext(r.Context(), "request timed out", "error", err)
+		}
+	case http.StatusInternalServerError:
+		detail = "Internal server error"
+		if r != nil {
+			slog.ErrorContext(r.Context(), "request failed", "error", err)
+			trace.SpanFromContext(r.Context()).RecordError(err)
+		}
+	}
+	p := New(r, status, code, detail)
+	var unknownField *apperrors.UnknownFieldError
+	if errors.As(err, &unknownField) {
+		p.Errors = []FieldError{{Field: unknownField.Field, Rule: "unknown"}}
+	}
+	var immutableField *apperrors.ImmutableFieldError
+	if errors.As(err, &immutableField) {
+		p.Errors = []FieldError{{Field: immutableField.Field, Rule: "immutable"}}
+	}
+	return p
+}
+
+func StatusForError(err error) (int, Code) {
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		return http.StatusNotFound, CodeNotFound
+	case errors.Is(err, apperrors.ErrInsufficientStock):
+		return http.StatusConflict, CodeInsufficientStock
+	case errors.Is(err, apperrors.ErrConflict):
+		return http.StatusConflict, CodeConflict
+	case errors.Is(err, apperrors.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, CodePreconditionFailed
+	case errors.Is(err, apperrors.ErrForbidden):
+		return http.StatusForbidden, CodeForbidden
+	case errors.As(err, new(*apperrors.ImmutableFieldError)):
+		return http.StatusUnprocessableEntity, CodeImmutableField
+	case errors.As(err, new(*apperrors.UnknownFieldError)):
+		return http.StatusBadRequest, CodeUnknownField
+	case errors.Is(err, apperrors.ErrInvalidInput):
+		return http.StatusBadRequest, CodeInvalidBody
+	case errors.Is(err, apperrors.ErrInvalidCredentials):
+		return http.StatusUnauthorized, CodeAuthFailed
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, CodeClientClosedRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable, CodeTimeout
+	default:
+		return http.StatusInternalServerError, CodeInternal
+	}
+}
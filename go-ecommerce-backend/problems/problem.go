@@ -0,0 +1,120 @@
+package problems
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+const ContentType = "application/problem+json"
+
+const StatusClientClosedRequest = 499
+
+type Code string
+
+const (
+	CodeInvalidID             Code = "invalid_id"
+	CodeInvalidBody           Code = "invalid_body"
+	CodeInvalidQuery          Code = "invalid_query"
+	CodeUnknownField          Code = "unknown_field"
+	CodeImmutableField        Code = "immutable_field"
+	CodeTrailingData          Code = "trailing_data"
+	CodeBodyTooLarge          Code = "body_too_large"
+	CodeValidationFailed      Code = "validation_failed"
+	CodeMissingToken          Code = "missing_token"
+	CodeInvalidToken          Code = "invalid_token"
+	CodeUnauthenticated       Code = "unauthenticated"
+	CodeAuthFailed            Code = "authentication_failed"
+	CodeForbidden             Code = "forbidden"
+	CodeNotFound              Code = "not_found"
+	CodeMethodNotAllowed      Code = "method_not_allowed"
+	CodeUnsupportedMediaType  Code = "unsupported_media_type"
+	CodeConflict              Code = "conflict"
+	CodeInsufficientStock     Code = "insufficient_stock"
+	CodePreconditionFailed    Code = "precondition_failed"
+	CodePreconditionRequired  Code = "precondition_required"
+	CodeInvalidIdempotencyKey Code = "invalid_idempotency_key"
+	CodeIdempotencyKeyReused  Code = "idempotency_key_reused"
+	CodeRequestInProgress     Code = "request_in_progress"
+	CodeRateLimited           Code = "rate_limited"
+	CodeTimeout               Code = "timeout"
+	CodeClientClosedRequest   Code = "client_closed_request"
+	CodeInternal              Code = "internal_error"
+)
+
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+func New(r *http.Request, status int, code Code, detail string) *Problem {
+	title := http.StatusText(status)
+	if status == StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	p := &Problem{
+		Type:   "about:blank",
+		Title:  title,
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+		p.RequestID = utils.RequestIDFromContext(r.Context())
+	}
+	return p
+}
+
+func (p *Problem) Write(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	utils.WriteJSON(w, r, p)
+}
+
+func Write(w http.ResponseWriter, r *http.Request, status int, code Code, detail string) {
+	New(r, status, code, detail).Write(w, r)
+}
+
+func WriteValidation(w http.ResponseWriter, r *http.Request, err error) {
+	p := New(r, http.StatusBadRequest, CodeValidationFailed, "Request body failed validation")
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		p.Code = CodeInvalidBody
+		p.Detail = "Invalid request body"
+		p.Write(w, r)
+		return
+	}
+
+	for _, fe := range validationErrors {
+		p.Errors = append(p.Errors, FieldError{
+			Field: fieldPath(fe.Namespace()),
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+		})
+	}
+	p.Write(w, r)
+}
+
+func fieldPath(namespace string) string {
+	if _, path, found := strings.Cut(namespace, "."); found {
+		return path
+	}
+	return namespace
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+type MemoryStore struct {
+	Now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{Now: time.Now, buckets: map[string]*bucket{}}
+}
+
+func (ms *MemoryStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := ms.Now()
+	capacity := float64(policy.Capacity())
+	b, ok := ms.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updatedAt: now}
+		ms.buckets[key] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updatedAt).Seconds()*policy.Rate())
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return result(policy, b.tokens, allowed), nil
+}
+
+func (ms *MemoryStore) Sweep(ctx context.Context, idle time.Duration) (int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var deleted int64
+	cutoff := ms.Now().Add(-idle)
+	for key, b := range ms.buckets {
+		if b.updatedAt.Before(cutoff) {
+			delete(ms.buckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type KeyKind string
+
+const (
+	KeyIP     KeyKind = "ip"
+	KeyUser   KeyKind = "user"
+	KeyAPIKey KeyKind = "api_key"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+
+	DefaultPolicy = "default"
+)
+
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Burst  int
+	Key    KeyKind
+}
+
+func (p Policy) Capacity() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+func (p Policy) Rate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+func (p *Policy) UnmarshalText(text []byte) error {
+	policy, err := parsePolicy(p.Name, strings.Fields(string(text)))
+	if err != nil {
+		return err
+	}
+	*p = policy
+	return nil
+}
+
+type Policies map[string]Policy
+
+func (p *Policies) UnmarshalText(text []byte) error {
+	policies, err := ParsePolicies(string(text))
+	if err != nil {
+		return err
+	}
+	*p = policies
+	return nil
+}
+
+type TrustedProxies []netip.Prefix
+
+func (t *TrustedProxies) UnmarshalText(text []byte) error {
+	var proxies TrustedProxies
+	for _, entry := range strings.Split(string(text), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(entry)
+			if addrErr != nil {
+				return fmt.Errorf("trusted proxy %q: expected an IP address or CIDR", entry)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	*t = proxies
+	return nil
+}
+
+func (t TrustedProxies) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+type Config struct {
+	Enabled        bool           `yaml:"enabled"`
+	Backend        string         `yaml:"backend" validate:"oneof=memory postgres"`
+	TrustedProxies TrustedProxies `yaml:"trusted_proxies"`
+	Policies       Policies       `yaml:"policies"`
+}
+
+func (c Config) PolicyFor(method, route string) (Policy, bool) {
+	if policy, ok := c.Policies[method+" "+route]; ok {
+		return policy, true
+	}
+	policy, ok := c.Policies[DefaultPolicy]
+	return policy, ok
+}
+
+func ParsePolicies(value string) (Policies, error) {
+	policies := Policies{}
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, spec, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("rate limit policy %q: expected \"name: limit/window\"", entry)
+		}
+		policy, err := parsePolicy(strings.TrimSpace(name), strings.Fields(spec))
+		if err != nil {
+			return nil, fmt.Errorf("rate limit policy %q: %w", entry, err)
+		}
+		policies[policy.Name] = policy
+	}
+	return policies, nil
+}
+
+func parsePolicy(name string, fields []string) (Policy, error) {
+	policy := Policy{Name: name, Key: KeyIP}
+	if len(fields) == 0 {
+		return policy, fmt.Errorf("missing limit/window")
+	}
+
+	limit, window, found := strings.Cut(fields[0], "/")
+	if !found {
+		return policy, fmt.Errorf("limit must be written as limit/window")
+	}
+	var err error
+	if policy.Limit, err = strconv.Atoi(limit); err != nil || policy.Limit <= 0 {
+		return policy, fmt.Errorf("invalid limit %q", limit)
+	}
+	if policy.Window, err = time.ParseDuration(window); err != nil || policy.Window <= 0 {
+		return policy, fmt.Errorf("invalid window %q", window)
+	}
+
+	for _, field := range fields[1:] {
+		option, value, _ := strings.Cut(field, "=")
+		switch option {
+		case "burst":
+			if policy.Burst, err = strconv.Atoi(value); err != nil || policy.Burst <= 0 {
+				return policy, fmt.Errorf("invalid burst %q", value)
+			}
+		case "key":
+			switch kind := KeyKind(value); kind {
+			case KeyIP, KeyUser, KeyAPIKey:
+				policy.Key = kind
+			default:
+				return policy, fmt.Errorf("invalid key %q", value)
+			}
+		default:
+			return policy, fmt.Errorf("unknown option %q", option)
+		}
+	}
+	return policy, nil
+}
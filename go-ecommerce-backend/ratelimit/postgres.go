@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+const refilledTokens = "LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $3::float8)"
+
+var takeQuery = strings.ReplaceAll(`INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at) VALUES ($1, $2::float8 - 1, TRUE, NOW())
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = REFILLED - CASE WHEN REFILLED >= 1 THEN 1 ELSE 0 END,
+		allowed = REFILLED >= 1,
+		updated_at = NOW()
+	RETURNING tokens, allowed`, "REFILLED", refilledTokens)
+
+type PostgresStore struct {
+	DB *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+func (ps *PostgresStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	var tokens float64
+	var allowed bool
+	err := ps.DB.QueryRowContext(ctx, takeQuery, key, policy.Capacity(), policy.Rate()).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(policy, tokens, allowed), nil
+}
+
+func (ps *PostgresStore) Sweep(ctx context.Context, idle time.Duration) (int64, error) {
+	query := "DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - make_interval(secs => $1)"
+	res, err := ps.DB.ExecContext(ctx, query, idle.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
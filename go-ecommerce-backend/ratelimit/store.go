@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+	Sweep(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+func NewStore(backend string, db *sql.DB) (Store, error) {
+	switch backend {
+	case BackendMemory, "":
+		return NewMemoryStore(), nil
+	case BackendPostgres:
+		return NewPostgresStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", backend)
+	}
+}
+
+func result(policy Policy, tokens float64, allowed bool) Result {
+	capacity := float64(policy.Capacity())
+	rate := policy.Rate()
+
+	res := Result{
+		Allowed:   allowed,
+		Limit:     policy.Limit,
+		Remaining: min(int(math.Floor(tokens)), policy.Limit),
+		Reset:     seconds((capacity - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(math.Max(s, 0))) * time.Second
+}
@@ -1,46 +1,64 @@
 package repositories
 
 import (
-	"database/sql"
+	"context"
 
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
 )
 
 type AddressRepository interface {
-	Create(address *models.Address) error
-	GetByID(id int) (*models.Address, error)
-	GetByCustomerID(id int) ([]*models.Address, error)
-	Update(address *models.Address) error
-	Delete(id int) error
-	GetOwnerID(id int) (int, error)
+	Create(ctx context.Context, address *models.Address) error
+	GetByID(ctx context.Context, id int) (*models.Address, error)
+	GetByCustomerID(ctx context.Context, id int) ([]*models.Address, error)
+	Update(ctx context.Context, address *models.Address) error
+	Patch(ctx context.Context, address *models.Address, columns []string) error
+	Delete(ctx context.Context, id int, version int) error
+	GetOwnerID(ctx context.Context, id int) (int, error)
 }
 
 type addressRepository struct {
-	DB *sql.DB
+	DB       Querier
+	Timeouts Timeouts
 }
 
-func NewAddressRepository(db *sql.DB) AddressRepository {
-	return &addressRepository{DB: db}
+func NewAddressRepository(db Querier, timeouts Timeouts) AddressRepository {
+	return &addressRepository{DB: db, Timeouts: timeouts}
 }
 
-func (ar *addressRepository) Create(address *models.Address) error {
-	query := "INSERT INTO addresses (customer_id, street_address, city, country) VALUES ($1, $2, $3, $4) RETURNING id"
-	return ar.DB.QueryRow(query, address.CustomerID, address.StreetAddress, address.City, address.Country).Scan(&address.ID)
+func (ar *addressRepository) Create(ctx context.Context, address *models.Address) error {
+	ctx, cancel := ar.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "INSERT INTO addresses (customer_id, street_address, city, country) VALUES ($1, $2, $3, $4) RETURNING id, version"
+	ctx, span := startSpan(ctx, "AddressRepository.Create", query)
+	defer span.End()
+	err := ar.DB.QueryRowContext(ctx, query, address.CustomerID, address.StreetAddress, address.City, address.Country).Scan(&address.ID, &address.Version)
+	return translateError(ctx, err, "address")
 }
 
-func (ar *addressRepository) GetByID(id int) (*models.Address, error) {
+func (ar *addressRepository) GetByID(ctx context.Context, id int) (*models.Address, error) {
+	ctx, cancel := ar.Timeouts.read(ctx)
+	defer cancel()
+
 	address := &models.Address{}
-	query := "SELECT id, customer_id, street_address, city, country FROM addresses WHERE id = $1"
-	err := ar.DB.QueryRow(query, id).Scan(&address.ID, &address.CustomerID, &address.StreetAddress, &address.City, &address.Country)
+	query := "SELECT id, customer_id, street_address, city, country, version FROM addresses WHERE id = $1"
+	ctx, span := startSpan(ctx, "AddressRepository.GetByID", query)
+	defer span.End()
+	err := ar.DB.QueryRowContext(ctx, query, id).Scan(&address.ID, &address.CustomerID, &address.StreetAddress, &address.City, &address.Country, &address.Version)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err, "address")
 	}
 	return address, nil
 }
 
-func (ar *addressRepository) GetByCustomerID(id int) ([]*models.Address, error) {
-	query := "SELECT id, customer_id, street_address, city, country FROM addresses WHERE customer_id = $1"
-	rows, err := ar.DB.Query(query, id)
+func (ar *addressRepository) GetByCustomerID(ctx context.Context, id int) ([]*models.Address, error) {
+	ctx, cancel := ar.Timeouts.read(ctx)
+	defer cancel()
+
+	query := "SELECT id, customer_id, street_address, city, country, version FROM addresses WHERE customer_id = $1"
+	ctx, span := startSpan(ctx, "AddressRepository.GetByCustomerID", query)
+	defer span.End()
+	rows, err := ar.DB.QueryContext(ctx, query, id)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +67,7 @@ func (ar *addressRepository) GetByCustomerID(id int) ([]*models.Address, error)
 	var addresses []*models.Address
 	for rows.Next() {
 		var address = new(models.Address)
-		if err := rows.Scan(&address.ID, &address.CustomerID, &address.StreetAddress, &address.City, &address.Country); err != nil {
+		if err := rows.Scan(&address.ID, &address.CustomerID, &address.StreetAddress, &address.City, &address.Country, &address.Version); err != nil {
 			return nil, err
 		}
 		addresses = append(addresses, address)
@@ -57,24 +75,58 @@ func (ar *addressRepository) GetByCustomerID(id int) ([]*models.Address, error)
 	return addresses, nil
 }
 
-func (ar *addressRepository) Update(address *models.Address) error {
-	query := "UPDATE addresses SET street_address = $1, city = $2, country = $3 WHERE id = $4"
-	_, err := ar.DB.Exec(query, address.StreetAddress, address.City, address.Country, address.ID)
-	return err
+func (ar *addressRepository) Update(ctx context.Context, address *models.Address) error {
+	ctx, cancel := ar.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "UPDATE addresses SET street_address = $1, city = $2, country = $3, version = version + 1 WHERE id = $4 AND version = $5 RETURNING version"
+	ctx, span := startSpan(ctx, "AddressRepository.Update", query)
+	defer span.End()
+	err := ar.DB.QueryRowContext(ctx, query, address.StreetAddress, address.City, address.Country, address.ID, address.Version).Scan(&address.Version)
+	return translateVersionedError(ctx, ar.DB, err, "addresses", address.ID, "address")
 }
 
-func (ar *addressRepository) Delete(id int) error {
-	query := "DELETE FROM addresses WHERE id = $1"
-	_, err := ar.DB.Exec(query, id)
-	return err
+func (ar *addressRepository) Patch(ctx context.Context, address *models.Address, columns []string) error {
+	ctx, cancel := ar.Timeouts.write(ctx)
+	defer cancel()
+
+	values := map[string]any{
+		"street_address": address.StreetAddress,
+		"city":           address.City,
+		"country":        address.Country,
+	}
+	query, args, err := patchQuery("addresses", columns, values, address.ID, address.Version)
+	if err != nil {
+		return err
+	}
+	ctx, span := startSpan(ctx, "AddressRepository.Patch", query)
+	defer span.End()
+	err = ar.DB.QueryRowContext(ctx, query, args...).Scan(&address.Version)
+	return translateVersionedError(ctx, ar.DB, err, "addresses", address.ID, "address")
+}
+
+func (ar *addressRepository) Delete(ctx context.Context, id int, version int) error {
+	ctx, cancel := ar.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "DELETE FROM addresses WHERE id = $1 AND ($2 = 0 OR version = $2) RETURNING id"
+	ctx, span := startSpan(ctx, "AddressRepository.Delete", query)
+	defer span.End()
+	err := ar.DB.QueryRowContext(ctx, query, id, version).Scan(&id)
+	return translateVersionedError(ctx, ar.DB, err, "addresses", id, "address")
 }
 
-func (ar *addressRepository) GetOwnerID(id int) (int, error) {
+func (ar *addressRepository) GetOwnerID(ctx context.Context, id int) (int, error) {
+	ctx, cancel := ar.Timeouts.read(ctx)
+	defer cancel()
+
 	var userID int
 	query := "SELECT c.user_id FROM addresses a JOIN customers c ON c.id = a.customer_id WHERE a.id = $1"
-	err := ar.DB.QueryRow(query, id).Scan(&userID)
+	ctx, span := startSpan(ctx, "AddressRepository.GetOwnerID", query)
+	defer span.End()
+	err := ar.DB.QueryRowContext(ctx, query, id).Scan(&userID)
 	if err != nil {
-		return 0, err
+		return 0, translateError(ctx, err, "address")
 	}
 	return userID, err
 }
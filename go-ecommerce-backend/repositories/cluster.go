@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+type ReplicaConfig struct {
+	MaxLag        time.Duration
+	Stickiness    time.Duration
+	CheckInterval time.Duration
+	Probe         func(ctx context.Context, replica *sql.DB) (time.Duration, error)
+}
+
+type Cluster struct {
+	Primary Querier
+	Replica *sql.DB
+
+	cfg     ReplicaConfig
+	healthy atomic.Bool
+}
+
+type primaryKey struct{}
+
+func NewCluster(primary Querier, replica *sql.DB, cfg ReplicaConfig) *Cluster {
+	if cfg.Probe == nil {
+		cfg.Probe = ReplicationLag
+	}
+	return &Cluster{Primary: primary, Replica: replica, cfg: cfg}
+}
+
+func PrimaryOnly(db Querier) *Cluster {
+	return NewCluster(db, nil, ReplicaConfig{})
+}
+
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func (c *Cluster) Reader(ctx context.Context) Querier {
+	if c.Replica == nil || !c.healthy.Load() {
+		return c.Primary
+	}
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return c.Primary
+	}
+	return c.Replica
+}
+
+func (c *Cluster) HasReplica() bool {
+	return c.Replica != nil
+}
+
+func (c *Cluster) ReplicaHealthy() bool {
+	return c.healthy.Load()
+}
+
+func (c *Cluster) Stickiness() time.Duration {
+	return c.cfg.Stickiness
+}
+
+func (c *Cluster) CheckReplica(ctx context.Context) error {
+	lag, err := c.cfg.Probe(ctx, c.Replica)
+	if err == nil && lag > c.cfg.MaxLag {
+		err = fmt.Errorf("replica is %s behind, more than the allowed %s", lag, c.cfg.MaxLag)
+	}
+	c.healthy.Store(err == nil)
+	return err
+}
+
+func (c *Cluster) Monitor(ctx context.Context, logger *slog.Logger) {
+	if c.Replica == nil {
+		return
+	}
+	ticker := time.NewTicker(c.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		wasHealthy := c.healthy.Load()
+		checkCtx, cancel := context.WithTimeout(ctx, c.cfg.CheckInterval)
+		err := c.CheckReplica(checkCtx)
+		cancel()
+		switch {
+		case err != nil && wasHealthy:
+			logger.Warn("routing reads to the primary, replica unavailable", "error", err)
+		case err == nil && !wasHealthy:
+			logger.Info("routing reads to the replica")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func ReplicationLag(ctx context.Context, replica *sql.DB) (time.Duration, error) {
+	query := `SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`
+	var seconds float64
+	if err := replica.QueryRowContext(ctx, query).Scan(&seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -1,60 +1,107 @@
 package repositories
 
 import (
-	"database/sql"
+	"context"
 
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
 )
 
 type CustomerRepository interface {
-	Create(customer *models.Customer) error
-	GetByID(id int) (*models.Customer, error)
-	GetByUserID(id int) (*models.Customer, error)
-	Update(customer *models.Customer) error
-	Delete(id int) error
+	Create(ctx context.Context, customer *models.Customer) error
+	GetByID(ctx context.Context, id int) (*models.Customer, error)
+	GetByUserID(ctx context.Context, id int) (*models.Customer, error)
+	Update(ctx context.Context, customer *models.Customer) error
+	Patch(ctx context.Context, customer *models.Customer, columns []string) error
+	Delete(ctx context.Context, id int, version int) error
 }
 
 type customerRepository struct {
-	DB *sql.DB
+	DB       Querier
+	Timeouts Timeouts
 }
 
-func NewCustomerRepository(db *sql.DB) CustomerRepository {
-	return &customerRepository{DB: db}
+func NewCustomerRepository(db Querier, timeouts Timeouts) CustomerRepository {
+	return &customerRepository{DB: db, Timeouts: timeouts}
 }
 
-func (cr *customerRepository) Create(customer *models.Customer) error {
-	query := "INSERT INTO customers (user_id, first_name, last_name, phone_number) VALUES ($1, $2, $3, $4) RETURNING id"
-	return cr.DB.QueryRow(query, customer.UserID, customer.FirstName, customer.LastName, customer.PhoneNumber).Scan(&customer.ID)
+func (cr *customerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	ctx, cancel := cr.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "INSERT INTO customers (user_id, first_name, last_name, phone_number) VALUES ($1, $2, $3, $4) RETURNING id, version"
+	ctx, span := startSpan(ctx, "CustomerRepository.Create", query)
+	defer span.End()
+	err := cr.DB.QueryRowContext(ctx, query, customer.UserID, customer.FirstName, customer.LastName, customer.PhoneNumber).Scan(&customer.ID, &customer.Version)
+	return translateError(ctx, err, "customer")
 }
 
-func (cr *customerRepository) GetByID(id int) (*models.Customer, error) {
+func (cr *customerRepository) GetByID(ctx context.Context, id int) (*models.Customer, error) {
+	ctx, cancel := cr.Timeouts.read(ctx)
+	defer cancel()
+
 	customer := &models.Customer{}
-	query := "SELECT id, user_id, first_name, last_name, phone_number FROM customers WHERE id = $1"
-	err := cr.DB.QueryRow(query, id).Scan(&customer.ID, &customer.UserID, &customer.FirstName, &customer.LastName, &customer.PhoneNumber)
+	query := "SELECT id, user_id, first_name, last_name, phone_number, version FROM customers WHERE id = $1"
+	ctx, span := startSpan(ctx, "CustomerRepository.GetByID", query)
+	defer span.End()
+	err := cr.DB.QueryRowContext(ctx, query, id).Scan(&customer.ID, &customer.UserID, &customer.FirstName, &customer.LastName, &customer.PhoneNumber, &customer.Version)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err, "customer")
 	}
 	return customer, nil
 }
 
-func (cr *customerRepository) GetByUserID(id int) (*models.Customer, error) {
+func (cr *customerRepository) GetByUserID(ctx context.Context, id int) (*models.Customer, error) {
+	ctx, cancel := cr.Timeouts.read(ctx)
+	defer cancel()
+
 	customer := &models.Customer{}
-	query := "SELECT id, user_id, first_name, last_name, phone_number FROM customers WHERE user_id = $1"
-	err := cr.DB.QueryRow(query, id).Scan(&customer.ID, &customer.UserID, &customer.FirstName, &customer.LastName, &customer.PhoneNumber)
+	query := "SELECT id, user_id, first_name, last_name, phone_number, version FROM customers WHERE user_id = $1"
+	ctx, span := startSpan(ctx, "CustomerRepository.GetByUserID", query)
+	defer span.End()
+	err := cr.DB.QueryRowContext(ctx, query, id).Scan(&customer.ID, &customer.UserID, &customer.FirstName, &customer.LastName, &customer.PhoneNumber, &customer.Version)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err, "customer")
 	}
 	return customer, nil
 }
 
-func (cr *customerRepository) Update(customer *models.Customer) error {
-	query := "UPDATE customers SET first_name = $1, last_name = $2, phone_number = $3 WHERE id = $4"
-	_, err := cr.DB.Exec(query, customer.FirstName, customer.LastName, customer.PhoneNumber, customer.ID)
-	return err
+func (cr *customerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	ctx, cancel := cr.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "UPDATE customers SET first_name = $1, last_name = $2, phone_number = $3, version = version + 1 WHERE id = $4 AND version = $5 RETURNING version"
+	ctx, span := startSpan(ctx, "CustomerRepository.Update", query)
+	defer span.End()
+	err := cr.DB.QueryRowContext(ctx, query, customer.FirstName, customer.LastName, customer.PhoneNumber, customer.ID, customer.Version).Scan(&customer.Version)
+	return translateVersionedError(ctx, cr.DB, err, "customers", customer.ID, "customer")
 }
 
-func (cr *customerRepository) Delete(id int) error {
-	query := "DELETE FROM customers WHERE id = $1"
-	_, err := cr.DB.Exec(query, id)
-	return err
+func (cr *customerRepository) Patch(ctx context.Context, customer *models.Customer, columns []string) error {
+	ctx, cancel := cr.Timeouts.write(ctx)
+	defer cancel()
+
+	values := map[string]any{
+		"first_name":   customer.FirstName,
+		"last_name":    customer.LastName,
+		"phone_number": customer.PhoneNumber,
+	}
+	query, args, err := patchQuery("customers", columns, values, customer.ID, customer.Version)
+	if err != nil {
+		return err
+	}
+	ctx, span := startSpan(ctx, "CustomerRepository.Patch", query)
+	defer span.End()
+	err = cr.DB.QueryRowContext(ctx, query, args...).Scan(&customer.Version)
+	return translateVersionedError(ctx, cr.DB, err, "customers", customer.ID, "customer")
+}
+
+func (cr *customerRepository) Delete(ctx context.Context, id int, version int) error {
+	ctx, cancel := cr.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "DELETE FROM customers WHERE id = $1 AND ($2 = 0 OR version = $2) RETURNING id"
+	ctx, span := startSpan(ctx, "CustomerRepository.Delete", query)
+	defer span.End()
+	err := cr.DB.QueryRowContext(ctx, query, id, version).Scan(&id)
+	return translateVersionedError(ctx, cr.DB, err, "customers", id, "customer")
 }
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/lib/pq"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+)
+
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+	pqQueryCanceled       = "57014"
+)
+
+func translateError(ctx context.Context, err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperrors.NotFound(resource)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pqUniqueViolation:
+			return conflict(ctx, resource+" already exists", pqErr.Constraint)
+		case pqForeignKeyViolation:
+			return conflict(ctx, resource+" references or is referenced by another resource", pqErr.Constraint)
+		case pqQueryCanceled:
+			return fmt.Errorf("%w: %w", context.DeadlineExceeded, err)
+		}
+	}
+	return err
+}
+
+func conflict(ctx context.Context, message, constraint string) error {
+	slog.InfoContext(ctx, "constraint violation", "error", message, "constraint", constraint)
+	return apperrors.Conflict(message)
+}
+
+func translateVersionedError(ctx context.Context, db Querier, err error, table string, id int, resource string) error {
+	if !errors.Is(err, sql.ErrNoRows) {
+		return translateError(ctx, err, resource)
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM " + table + " WHERE id = $1)"
+	if err := db.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return apperrors.PreconditionFailed(resource)
+	}
+	return apperrors.NotFound(resource)
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+)
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record *models.IdempotencyRecord, ttl, lease time.Duration) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, record *models.IdempotencyRecord) error
+	Release(ctx context.Context, record *models.IdempotencyRecord) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type idempotencyRepository struct {
+	DB       Querier
+	Timeouts Timeouts
+}
+
+func NewIdempotencyRepository(db Querier, timeouts Timeouts) IdempotencyRepository {
+	return &idempotencyRepository{DB: db, Timeouts: timeouts}
+}
+
+func (ir *idempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord, ttl, lease time.Duration) (*models.IdempotencyRecord, error) {
+	ctx, cancel := ir.Timeouts.write(ctx)
+	defer cancel()
+
+	query := `INSERT INTO idempotency_keys (user_id, key, fingerprint, expires_at, locked_until) VALUES ($1, $2, $3, NOW() + make_interval(secs => $4), NOW() + make_interval(secs => $5))
+		ON CONFLICT (user_id, key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status = NULL, headers = NULL, body = NULL, created_at = NOW(), expires_at = EXCLUDED.expires_at, locked_until = EXCLUDED.locked_until
+		WHERE idempotency_keys.expires_at <= NOW() OR (idempotency_keys.status IS NULL AND idempotency_keys.locked_until <= NOW())
+		RETURNING expires_at, locked_until`
+	ctx, span := startSpan(ctx, "IdempotencyRepository.Reserve", query)
+	defer span.End()
+	err := ir.DB.QueryRowContext(ctx, query, record.UserID, record.Key, record.Fingerprint, ttl.Seconds(), lease.Seconds()).Scan(&record.ExpiresAt, &record.LockedUntil)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	existing := &models.IdempotencyRecord{UserID: record.UserID, Key: record.Key}
+	var headers []byte
+	var lockedUntil sql.NullTime
+	selectQuery := "SELECT fingerprint, COALESCE(status, 0), headers, body, expires_at, locked_until FROM idempotency_keys WHERE user_id = $1 AND key = $2"
+	err = ir.DB.QueryRowContext(ctx, selectQuery, record.UserID, record.Key).Scan(&existing.Fingerprint, &existing.Status, &headers, &existing.Body, &existing.ExpiresAt, &lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperrors.Conflict("idempotency key was released by a concurrent request, retry the request")
+	}
+	if err != nil {
+		return nil, err
+	}
+	existing.LockedUntil = lockedUntil.Time
+	if headers != nil {
+		if err := json.Unmarshal(headers, &existing.Headers); err != nil {
+			return nil, err
+		}
+	}
+	return existing, nil
+}
+
+func (ir *idempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	ctx, cancel := ir.Timeouts.write(ctx)
+	defer cancel()
+
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE idempotency_keys SET status = $1, headers = $2, body = $3 WHERE user_id = $4 AND key = $5"
+	ctx, span := startSpan(ctx, "IdempotencyRepository.Complete", query)
+	defer span.End()
+	_, err = ir.DB.ExecContext(ctx, query, record.Status, headers, record.Body, record.UserID, record.Key)
+	return err
+}
+
+func (ir *idempotencyRepository) Release(ctx context.Context, record *models.IdempotencyRecord) error {
+	ctx, cancel := ir.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status IS NULL"
+	ctx, span := startSpan(ctx, "IdempotencyRepository.Release", query)
+	defer span.End()
+	_, err := ir.DB.ExecContext(ctx, query, record.UserID, record.Key)
+	return err
+}
+
+func (ir *idempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx, cancel := ir.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "DELETE FROM idempotency_keys WHERE expires_at <= NOW()"
+	ctx, span := startSpan(ctx, "IdempotencyRepository.DeleteExpired", query)
+	defer span.End()
+	result, err := ir.DB.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+)
+
+type memoryAddressRepository struct {
+	store *MemoryStore
+}
+
+func (mr *memoryAddressRepository) Create(ctx context.Context, address *models.Address) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, ok := mr.store.customers[address.CustomerID]; !ok {
+		return referenceViolation(ctx, "address", "addresses_customer_id_fkey")
+	}
+	address.ID = mr.store.nextID("addresses")
+	address.Version = 1
+	mr.store.addresses[address.ID] = *address
+	return nil
+}
+
+func (mr *memoryAddressRepository) GetByID(ctx context.Context, id int) (*models.Address, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	address, ok := mr.store.addresses[id]
+	if !ok {
+		return nil, apperrors.NotFound("address")
+	}
+	return &address, nil
+}
+
+func (mr *memoryAddressRepository) GetByCustomerID(ctx context.Context, id int) ([]*models.Address, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var addresses []*models.Address
+	for _, addressID := range sortedIDs(mr.store.addresses) {
+		if address := mr.store.addresses[addressID]; address.CustomerID == id {
+			addresses = append(addresses, &address)
+		}
+	}
+	return addresses, nil
+}
+
+func (mr *memoryAddressRepository) Update(ctx context.Context, address *models.Address) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.addresses[address.ID]
+	if err := checkUpdateVersion(ok, current.Version, address.Version, "address"); err != nil {
+		return err
+	}
+	current.StreetAddress, current.City, current.Country = address.StreetAddress, address.City, address.Country
+	current.Version++
+	mr.store.addresses[address.ID] = current
+	address.Version = current.Version
+	return nil
+}
+
+func (mr *memoryAddressRepository) Patch(ctx context.Context, address *models.Address, columns []string) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.addresses[address.ID]
+	for _, column := range columns {
+		switch column {
+		case "street_address":
+			current.StreetAddress = address.StreetAddress
+		case "city":
+			current.City = address.City
+		case "country":
+			current.Country = address.Country
+		default:
+			return unknownColumn("addresses", column)
+		}
+	}
+	if err := checkUpdateVersion(ok, current.Version, address.Version, "address"); err != nil {
+		return err
+	}
+	current.Version++
+	mr.store.addresses[address.ID] = current
+	address.Version = current.Version
+	return nil
+}
+
+func (mr *memoryAddressRepository) Delete(ctx context.Context, id int, version int) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.addresses[id]
+	if err := checkVersion(ok, current.Version, version, "address"); err != nil {
+		return err
+	}
+	delete(mr.store.addresses, id)
+	return nil
+}
+
+func (mr *memoryAddressRepository) GetOwnerID(ctx context.Context, id int) (int, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	address, ok := mr.store.addresses[id]
+	if !ok {
+		return 0, apperrors.NotFound("address")
+	}
+	userID, ok := mr.store.ownerOf(address.CustomerID)
+	if !ok {
+		return 0, apperrors.NotFound("address")
+	}
+	return userID, nil
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+)
+
+type memoryCustomerRepository struct {
+	store *MemoryStore
+}
+
+func (mr *memoryCustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, ok := mr.store.users[customer.UserID]; !ok {
+		return referenceViolation(ctx, "customer", "customers_user_id_fkey")
+	}
+	for _, existing := range mr.store.customers {
+		if existing.UserID == customer.UserID {
+			return uniqueViolation(ctx, "customer", "unique_user")
+		}
+	}
+	customer.ID = mr.store.nextID("customers")
+	customer.Version = 1
+	mr.store.customers[customer.ID] = *customer
+	return nil
+}
+
+func (mr *memoryCustomerRepository) GetByID(ctx context.Context, id int) (*models.Customer, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	customer, ok := mr.store.customers[id]
+	if !ok {
+		return nil, apperrors.NotFound("customer")
+	}
+	return &customer, nil
+}
+
+func (mr *memoryCustomerRepository) GetByUserID(ctx context.Context, id int) (*models.Customer, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	for _, customer := range mr.store.customers {
+		if customer.UserID == id {
+			return &customer, nil
+		}
+	}
+	return nil, apperrors.NotFound("customer")
+}
+
+func (mr *memoryCustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.customers[customer.ID]
+	if err := checkUpdateVersion(ok, current.Version, customer.Version, "customer"); err != nil {
+		return err
+	}
+	current.FirstName, current.LastName, current.PhoneNumber = customer.FirstName, customer.LastName, customer.PhoneNumber
+	current.Version++
+	mr.store.customers[customer.ID] = current
+	customer.Version = current.Version
+	return nil
+}
+
+func (mr *memoryCustomerRepository) Patch(ctx context.Context, customer *models.Customer, columns []string) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.customers[customer.ID]
+	for _, column := range columns {
+		switch column {
+		case "first_name":
+			current.FirstName = customer.FirstName
+		case "last_name":
+			current.LastName = customer.LastName
+		case "phone_number":
+			current.PhoneNumber = customer.PhoneNumber
+		default:
+			return unknownColumn("customers", column)
+		}
+	}
+	if err := checkUpdateVersion(ok, current.Version, customer.Version, "customer"); err != nil {
+		return err
+	}
+	current.Version++
+	mr.store.customers[customer.ID] = current
+	customer.Version = current.Version
+	return nil
+}
+
+func (mr *memoryCustomerRepository) Delete(ctx context.Context, id int, version int) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.customers[id]
+	if err := checkVersion(ok, current.Version, version, "customer"); err != nil {
+		return err
+	}
+	if mr.store.customerHasOrders(id) {
+		return referenceViolation(ctx, "customer", "orders_customer_id_fkey")
+	}
+	mr.store.deleteCustomerCascade(id)
+	return nil
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+)
+
+type memoryIdempotencyRepository struct {
+	store *MemoryStore
+}
+
+func (mr *memoryIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord, ttl, lease time.Duration) (*models.IdempotencyRecord, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	key := idempotencyKey{userID: record.UserID, key: record.Key}
+	now := mr.store.Now()
+	if existing, ok := mr.store.idempotency[key]; ok && existing.ExpiresAt.After(now) && (existing.Completed() || existing.LockedUntil.After(now)) {
+		existing.Headers = existing.Headers.Clone()
+		return &existing, nil
+	}
+	record.ExpiresAt, record.LockedUntil = now.Add(ttl), now.Add(lease)
+	mr.store.idempotency[key] = models.IdempotencyRecord{UserID: record.UserID, Key: record.Key, Fingerprint: record.Fingerprint, ExpiresAt: record.ExpiresAt, LockedUntil: record.LockedUntil}
+	return nil, nil
+}
+
+func (mr *memoryIdempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	key := idempotencyKey{userID: record.UserID, key: record.Key}
+	existing, ok := mr.store.idempotency[key]
+	if !ok {
+		return nil
+	}
+	existing.Status = record.Status
+	existing.Headers = record.Headers.Clone()
+	existing.Body = append([]byte(nil), record.Body...)
+	mr.store.idempotency[key] = existing
+	return nil
+}
+
+func (mr *memoryIdempotencyRepository) Release(ctx context.Context, record *models.IdempotencyRecord) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	key := idempotencyKey{userID: record.UserID, key: record.Key}
+	if existing, ok := mr.store.idempotency[key]; ok && !existing.Completed() {
+		delete(mr.store.idempotency, key)
+	}
+	return nil
+}
+
+func (mr *memoryIdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var deleted int64
+	now := mr.store.Now()
+	for key, record := range mr.store.idempotency {
+		if !record.ExpiresAt.After(now) {
+			delete(mr.store.idempotency, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+)
+
+type memoryOrderRepository struct {
+	store *MemoryStore
+}
+
+func (mr *memoryOrderRepository) Create(ctx context.Context, order *models.Order) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, ok := mr.store.customers[order.CustomerID]; !ok {
+		return referenceViolation(ctx, "order", "orders_customer_id_fkey")
+	}
+
+	stock := map[int]int{}
+	reserved := map[int]bool{}
+	for _, item := range order.OrderItems {
+		product, ok := mr.store.products[item.ProductID]
+		if !ok {
+			return apperrors.NotFound("product")
+		}
+		available, seen := stock[item.ProductID]
+		if !seen {
+			available = product.Stock
+		}
+		if available < item.Quantity {
+			return &apperrors.InsufficientStockError{ProductID: item.ProductID, Available: available, Requested: item.Quantity}
+		}
+		stock[item.ProductID] = available - item.Quantity
+		if reserved[item.ProductID] {
+			return uniqueViolation(ctx, "order item", "unique_order_product")
+		}
+		reserved[item.ProductID] = true
+	}
+
+	order.ID = mr.store.nextID("orders")
+	order.CreatedAt = mr.store.now()
+	order.Version = 1
+	for productID, remaining := range stock {
+		product := mr.store.products[productID]
+		product.Stock = remaining
+		product.Version++
+		mr.store.products[productID] = product
+	}
+	var total float64
+	for _, item := range order.OrderItems {
+		item.ID = mr.store.nextID("order_items")
+		item.OrderID = order.ID
+		item.UnitPrice = mr.store.products[item.ProductID].Price
+		mr.store.orderItems[item.ID] = item
+		total += item.UnitPrice * float64(item.Quantity)
+	}
+
+	order.Total = models.RoundMoney(total)
+	stored := *order
+	stored.OrderItems = nil
+	mr.store.orders[order.ID] = stored
+	return nil
+}
+
+func (mr *memoryOrderRepository) GetByID(ctx context.Context, id int) (*models.Order, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	order, ok := mr.store.orders[id]
+	if !ok {
+		return nil, apperrors.NotFound("order")
+	}
+	for _, itemID := range sortedIDs(mr.store.orderItems) {
+		if item := mr.store.orderItems[itemID]; item.OrderID == id {
+			order.OrderItems = append(order.OrderItems, item)
+		}
+	}
+	return &order, nil
+}
+
+func (mr *memoryOrderRepository) List(ctx context.Context, filter OrderFilter) ([]*models.Order, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	products := map[int][]int{}
+	for _, item := range mr.store.orderItems {
+		products[item.OrderID] = append(products[item.OrderID], item.ProductID)
+	}
+
+	var orders []*models.Order
+	for _, order := range mr.store.orders {
+		if filter.ProductID != 0 && !slices.Contains(products[order.ID], filter.ProductID) {
+			continue
+		}
+		if matchesOrderFilter(order, filter) {
+			orders = append(orders, &order)
+		}
+	}
+	slices.SortFunc(orders, func(a, b *models.Order) int {
+		return compareOrders(a, b, filter)
+	})
+	if filter.Limit > 0 && len(orders) > filter.Limit {
+		orders = orders[:filter.Limit]
+	}
+
+	if filter.WithItems {
+		byID := make(map[int]*models.Order, len(orders))
+		for _, order := range orders {
+			byID[order.ID] = order
+		}
+		for _, itemID := range sortedIDs(mr.store.orderItems) {
+			item := mr.store.orderItems[itemID]
+			if order, ok := byID[item.OrderID]; ok {
+				order.OrderItems = append(order.OrderItems, item)
+			}
+		}
+	}
+	return orders, nil
+}
+
+func (mr *memoryOrderRepository) Stream(ctx context.Context, filter OrderFilter, fn func(order *models.Order) error) error {
+	filter.WithItems = false
+	orders, err := mr.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if err := fn(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compareOrders(a, b *models.Order, filter OrderFilter) int {
+	c := a.CreatedAt.Compare(b.CreatedAt)
+	if filter.Sort == OrderSortTotal {
+		c = cmp.Compare(a.Total, b.Total)
+	}
+	c = cmp.Or(c, cmp.Compare(a.ID, b.ID))
+	if filter.Ascending {
+		return c
+	}
+	return -c
+}
+
+func matchesOrderFilter(order models.Order, filter OrderFilter) bool {
+	if filter.CustomerID != 0 && order.CustomerID != filter.CustomerID {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, order.Status) {
+		return false
+	}
+	if !filter.CreatedFrom.IsZero() && order.CreatedAt.Before(filter.CreatedFrom) {
+		return false
+	}
+	if !filter.CreatedBefore.IsZero() && !order.CreatedAt.Before(filter.CreatedBefore) {
+		return false
+	}
+	if filter.MinTotal != nil && order.Total < *filter.MinTotal {
+		return false
+	}
+	if filter.MaxTotal != nil && order.Total > *filter.MaxTotal {
+		return false
+	}
+	if after := filter.After; after != nil {
+		cursor := &models.Order{ID: after.ID, CreatedAt: after.CreatedAt, Total: after.Total}
+		return compareOrders(&order, cursor, filter) > 0
+	}
+	return true
+}
+
+func (mr *memoryOrderRepository) Update(ctx context.Context, order *models.Order) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.orders[order.ID]
+	if err := checkUpdateVersion(ok, current.Version, order.Version, "order"); err != nil {
+		return err
+	}
+	current.Status = order.Status
+	current.Version++
+	mr.store.orders[order.ID] = current
+	order.Version = current.Version
+	return nil
+}
+
+func (mr *memoryOrderRepository) GetOwnerID(ctx context.Context, id int) (int, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	order, ok := mr.store.orders[id]
+	if !ok {
+		return 0, apperrors.NotFound("order")
+	}
+	userID, ok := mr.store.ownerOf(order.CustomerID)
+	if !ok {
+		return 0, apperrors.NotFound("order")
+	}
+	return userID, nil
+}
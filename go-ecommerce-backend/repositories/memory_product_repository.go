@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+)
+
+type memoryProductRepository struct {
+	store *MemoryStore
+}
+
+func checkProduct(product models.Product) error {
+	if product.Price <= 0 {
+		return fmt.Errorf("product price must be greater than 0")
+	}
+	if product.Stock < 0 {
+		return fmt.Errorf("product stock must not be negative")
+	}
+	return nil
+}
+
+func (mr *memoryProductRepository) Create(ctx context.Context, product *models.Product) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := checkProduct(*product); err != nil {
+		return err
+	}
+	product.ID = mr.store.nextID("products")
+	product.Version = 1
+	mr.store.products[product.ID] = *product
+	return nil
+}
+
+func (mr *memoryProductRepository) GetByID(ctx context.Context, id int) (*models.Product, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	product, ok := mr.store.products[id]
+	if !ok {
+		return nil, apperrors.NotFound("product")
+	}
+	return &product, nil
+}
+
+func (mr *memoryProductRepository) Update(ctx context.Context, product *models.Product) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.products[product.ID]
+	if err := checkUpdateVersion(ok, current.Version, product.Version, "product"); err != nil {
+		return err
+	}
+	updated := *product
+	if err := checkProduct(updated); err != nil {
+		return err
+	}
+	updated.Version = current.Version + 1
+	mr.store.products[product.ID] = updated
+	product.Version = updated.Version
+	return nil
+}
+
+func (mr *memoryProductRepository) Patch(ctx context.Context, product *models.Product, columns []string) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.products[product.ID]
+	for _, column := range columns {
+		switch column {
+		case "name":
+			current.Name = product.Name
+		case "description":
+			current.Description = product.Description
+		case "category":
+			current.Category = product.Category
+		case "price":
+			current.Price = product.Price
+		case "stock":
+			current.Stock = product.Stock
+		default:
+			return unknownColumn("products", column)
+		}
+	}
+	if err := checkUpdateVersion(ok, current.Version, product.Version, "product"); err != nil {
+		return err
+	}
+	if err := checkProduct(current); err != nil {
+		return err
+	}
+	current.Version++
+	mr.store.products[product.ID] = current
+	product.Version = current.Version
+	return nil
+}
+
+func (mr *memoryProductRepository) Delete(ctx context.Context, id int, version int) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.products[id]
+	if err := checkVersion(ok, current.Version, version, "product"); err != nil {
+		return err
+	}
+	for _, item := range mr.store.orderItems {
+		if item.ProductID == id {
+			return referenceViolation(ctx, "product", "order_items_product_id_fkey")
+		}
+	}
+	delete(mr.store.products, id)
+	return nil
+}
+
+func (mr *memoryProductRepository) Restock(ctx context.Context, id int, quantity int) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	product, ok := mr.store.products[id]
+	if !ok {
+		return apperrors.NotFound("product")
+	}
+	product.Stock += quantity
+	product.Version++
+	mr.store.products[id] = product
+	return nil
+}
+
+func (mr *memoryProductRepository) GetAll(ctx context.Context) ([]*models.Product, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var products []*models.Product
+	for _, id := range sortedIDs(mr.store.products) {
+		product := mr.store.products[id]
+		products = append(products, &product)
+	}
+	return products, nil
+}
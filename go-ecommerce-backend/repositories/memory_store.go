@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+)
+
+type MemoryStore struct {
+	Now func() time.Time
+
+	mu   *sync.Mutex
+	held bool
+	*memoryTables
+}
+
+type memoryTables struct {
+	lastID      map[string]int
+	users       map[int]models.User
+	customers   map[int]models.Customer
+	addresses   map[int]models.Address
+	products    map[int]models.Product
+	orders      map[int]models.Order
+	orderItems  map[int]models.OrderItem
+	idempotency map[idempotencyKey]models.IdempotencyRecord
+}
+
+type idempotencyKey struct {
+	userID int
+	key    string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		Now: time.Now,
+		mu:  &sync.Mutex{},
+		memoryTables: &memoryTables{
+			lastID:      map[string]int{},
+			users:       map[int]models.User{},
+			customers:   map[int]models.Customer{},
+			addresses:   map[int]models.Address{},
+			products:    map[int]models.Product{},
+			orders:      map[int]models.Order{},
+			orderItems:  map[int]models.OrderItem{},
+			idempotency: map[idempotencyKey]models.IdempotencyRecord{},
+		},
+	}
+}
+
+func NewMemoryRepositories(store *MemoryStore) *Repositories {
+	return &Repositories{
+		Users:       &memoryUserRepository{store: store},
+		Customers:   &memoryCustomerRepository{store: store},
+		Addresses:   &memoryAddressRepository{store: store},
+		Products:    &memoryProductRepository{store: store},
+		Orders:      &memoryOrderRepository{store: store},
+		Idempotency: &memoryIdempotencyRepository{store: store},
+		Tx:          store,
+	}
+}
+
+func (s *MemoryStore) WithinTx(ctx context.Context, fn func(repos *Repositories) error) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tx := &MemoryStore{Now: s.Now, mu: s.mu, held: true, memoryTables: s.memoryTables.clone()}
+	if err := fn(NewMemoryRepositories(tx)); err != nil {
+		return err
+	}
+	s.memoryTables = tx.memoryTables
+	return nil
+}
+
+func (s *MemoryStore) lock(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.held {
+		return func() {}, nil
+	}
+	s.mu.Lock()
+	return s.mu.Unlock, nil
+}
+
+func (t *memoryTables) clone() *memoryTables {
+	return &memoryTables{
+		lastID:      maps.Clone(t.lastID),
+		users:       maps.Clone(t.users),
+		customers:   maps.Clone(t.customers),
+		addresses:   maps.Clone(t.addresses),
+		products:    maps.Clone(t.products),
+		orders:      maps.Clone(t.orders),
+		orderItems:  maps.Clone(t.orderItems),
+		idempotency: maps.Clone(t.idempotency),
+	}
+}
+
+func (s *MemoryStore) nextID(table string) int {
+	s.lastID[table]++
+	return s.lastID[table]
+}
+
+func (s *MemoryStore) now() time.Time {
+	return s.Now().UTC().Truncate(time.Second)
+}
+
+func sortedIDs[T any](rows map[int]T) []int {
+	ids := make([]int, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func checkVersion(exists bool, current, expected int, resource string) error {
+	if !exists {
+		return apperrors.NotFound(resource)
+	}
+	if expected != 0 && current != expected {
+		return apperrors.PreconditionFailed(resource)
+	}
+	return nil
+}
+
+func checkUpdateVersion(exists bool, current, expected int, resource string) error {
+	if !exists {
+		return apperrors.NotFound(resource)
+	}
+	if current != expected {
+		return apperrors.PreconditionFailed(resource)
+	}
+	return nil
+}
+
+func uniqueViolation(ctx context.Context, resource, constraint string) error {
+	return conflict(ctx, resource+" already exists", constraint)
+}
+
+func referenceViolation(ctx context.Context, resource, constraint string) error {
+	return conflict(ctx, resource+" references or is referenced by another resource", constraint)
+}
+
+func unknownColumn(table, column string) error {
+	return fmt.Errorf("column %q of %s cannot be patched", column, table)
+}
+
+func (s *MemoryStore) customerHasOrders(customerID int) bool {
+	for _, order := range s.orders {
+		if order.CustomerID == customerID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) deleteCustomerCascade(customerID int) {
+	for id, address := range s.addresses {
+		if address.CustomerID == customerID {
+			delete(s.addresses, id)
+		}
+	}
+	delete(s.customers, customerID)
+}
+
+func (s *MemoryStore) ownerOf(customerID int) (int, bool) {
+	customer, ok := s.customers[customerID]
+	return customer.UserID, ok
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+)
+
+type memoryUserRepository struct {
+	store *MemoryStore
+}
+
+func (mr *memoryUserRepository) emailTaken(email string, exceptID int) bool {
+	for id, user := range mr.store.users {
+		if user.Email == email && id != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (mr *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if mr.emailTaken(user.Email, 0) {
+		return uniqueViolation(ctx, "user", "users_email_key")
+	}
+	user.ID = mr.store.nextID("users")
+	user.CreatedAt = mr.store.now()
+	user.Version = 1
+	mr.store.users[user.ID] = *user
+	return nil
+}
+
+func (mr *memoryUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	user, ok := mr.store.users[id]
+	if !ok {
+		return nil, apperrors.NotFound("user")
+	}
+	return &user, nil
+}
+
+func (mr *memoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	for _, id := range sortedIDs(mr.store.users) {
+		if user := mr.store.users[id]; user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, apperrors.NotFound("user")
+}
+
+func (mr *memoryUserRepository) Update(ctx context.Context, user *models.User) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.users[user.ID]
+	if err := checkUpdateVersion(ok, current.Version, user.Version, "user"); err != nil {
+		return err
+	}
+	if mr.emailTaken(user.Email, user.ID) {
+		return uniqueViolation(ctx, "user", "users_email_key")
+	}
+	current.Email, current.Password, current.Role = user.Email, user.Password, user.Role
+	current.Version++
+	mr.store.users[user.ID] = current
+	user.Version = current.Version
+	return nil
+}
+
+func (mr *memoryUserRepository) Delete(ctx context.Context, id int, version int) error {
+	unlock, err := mr.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok := mr.store.users[id]
+	if err := checkVersion(ok, current.Version, version, "user"); err != nil {
+		return err
+	}
+	for customerID, customer := range mr.store.customers {
+		if customer.UserID != id {
+			continue
+		}
+		if mr.store.customerHasOrders(customerID) {
+			return referenceViolation(ctx, "user", "orders_customer_id_fkey")
+		}
+		mr.store.deleteCustomerCascade(customerID)
+	}
+	delete(mr.store.users, id)
+	return nil
+}
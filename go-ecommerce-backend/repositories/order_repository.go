@@ -1,88 +1,199 @@
 package repositories
 
 import (
-	"database/sql"
+	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/lib/pq"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type OrderRepository interface {
-	Create(order *models.Order) error
-	GetByID(id int) (*models.Order, error)
-	GetOrdersByCustomerID(id int) ([]*models.Order, error)
-	Update(order *models.Order) error
-	GetOwnerID(id int) (int, error)
+	Create(ctx context.Context, order *models.Order) error
+	GetByID(ctx context.Context, id int) (*models.Order, error)
+	List(ctx context.Context, filter OrderFilter) ([]*models.Order, error)
+	Stream(ctx context.Context, filter OrderFilter, fn func(order *models.Order) error) error
+	Update(ctx context.Context, order *models.Order) error
+	GetOwnerID(ctx context.Context, id int) (int, error)
+}
+
+type OrderSort string
+
+const (
+	OrderSortCreatedAt OrderSort = "created_at"
+	OrderSortTotal     OrderSort = "total"
+)
+
+type OrderFilter struct {
+	CustomerID    int
+	ProductID     int
+	Statuses      []models.OrderStatus
+	CreatedFrom   time.Time
+	CreatedBefore time.Time
+	MinTotal      *float64
+	MaxTotal      *float64
+	Sort          OrderSort
+	Ascending     bool
+	After         *OrderCursor
+	Limit         int
+	WithItems     bool
+}
+
+type OrderCursor struct {
+	CreatedAt time.Time
+	Total     float64
+	ID        int
+	Sort      OrderSort
+	Ascending bool
 }
 
 type orderRepository struct {
-	DB *sql.DB
+	DB       *Cluster
+	Timeouts Timeouts
 }
 
-func NewOrderRepository(db *sql.DB) OrderRepository {
-	return &orderRepository{DB: db}
+func NewOrderRepository(db *Cluster, timeouts Timeouts) OrderRepository {
+	return &orderRepository{DB: db, Timeouts: timeouts}
 }
 
-func (or *orderRepository) Create(order *models.Order) error {
-	tx, err := or.DB.Begin()
+var orderTxOptions = TxOptions{MaxAttempts: 3, Backoff: 20 * time.Millisecond}
+
+func (or *orderRepository) Create(ctx context.Context, order *models.Order) error {
+	ctx, cancel := or.Timeouts.write(ctx)
+	defer cancel()
+
+	shipping, err := marshalOrderAddress(order.ShippingAddress)
 	if err != nil {
 		return err
 	}
-
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
-
-	orderQuery := "INSERT INTO orders (customer_id, status) VALUES ($1, $2) RETURNING id, created_at"
-
-	err = tx.QueryRow(orderQuery, order.CustomerID, order.Status).Scan(&order.ID, &order.CreatedAt)
+	billing, err := marshalOrderAddress(order.BillingAddress)
 	if err != nil {
 		return err
 	}
 
-	for _, item := range order.OrderItems {
-		var available int
-		stockQuery := "SELECT stock FROM products WHERE id = $1 FOR UPDATE"
-		err = tx.QueryRow(stockQuery, item.ProductID).Scan(&available)
+	orderQuery := "INSERT INTO orders (customer_id, status, shipping_address, billing_address) VALUES ($1, $2, $3, $4) RETURNING id, created_at, version"
+	ctx, span := startSpan(ctx, "OrderRepository.Create", orderQuery)
+	defer span.End()
+
+	return runTx(ctx, or.DB.Primary, orderTxOptions, func(tx Querier) error {
+		err := tx.QueryRowContext(ctx, orderQuery, order.CustomerID, order.Status, shipping, billing).Scan(&order.ID, &order.CreatedAt, &order.Version)
 		if err != nil {
-			return err
+			return translateError(ctx, err, "order")
 		}
+		order.Total, err = or.reserveItems(ctx, tx, order.ID, order.OrderItems)
+		return err
+	})
+}
+
+func (or *orderRepository) reserveItems(ctx context.Context, tx Querier, orderID int, items []models.OrderItem) (float64, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	lockQuery := "SELECT id, stock FROM products WHERE id = ANY($1) ORDER BY id FOR UPDATE"
+	updateStockQuery := `UPDATE products p SET stock = p.stock - r.quantity, version = p.version + 1
+		FROM unnest($1::int[], $2::int[]) AS r(id, quantity)
+		WHERE p.id = r.id AND p.stock >= r.quantity`
+	itemsQuery := `WITH items AS (
+			INSERT INTO order_items (order_id, product_id, quantity, unit_price)
+			SELECT $1, r.product_id, r.quantity, p.price
+			FROM unnest($2::int[], $3::int[]) WITH ORDINALITY AS r(product_id, quantity, n)
+			JOIN products p ON p.id = r.product_id
+			ORDER BY r.n
+			RETURNING quantity, unit_price
+		)
+		UPDATE orders SET total = (SELECT COALESCE(SUM(quantity * unit_price), 0) FROM items) WHERE id = $1 RETURNING total`
+
+	ctx, span := startSpan(ctx, "OrderRepository.reserveItems", strings.Join([]string{lockQuery, updateStockQuery, itemsQuery}, ";\n"))
+	defer span.End()
+	span.SetAttributes(attribute.Int("order.items", len(items)))
 
-		if available < item.Quantity {
-			return fmt.Errorf("insufficient quantity for product %d: available %d, required %d", item.ProductID, available, item.Quantity)
+	requested := map[int64]int64{}
+	productIDs := make([]int64, 0, len(items))
+	itemProductIDs := make([]int64, 0, len(items))
+	quantities := make([]int64, 0, len(items))
+	for _, item := range items {
+		id := int64(item.ProductID)
+		if _, ok := requested[id]; !ok {
+			productIDs = append(productIDs, id)
 		}
+		requested[id] += int64(item.Quantity)
+		itemProductIDs = append(itemProductIDs, id)
+		quantities = append(quantities, int64(item.Quantity))
+	}
+	slices.Sort(productIDs)
 
-		updateStockQuery := "UPDATE products SET stock = stock - $1 WHERE id = $2"
-		_, err = tx.Exec(updateStockQuery, item.Quantity, item.ProductID)
-		if err != nil {
-			return err
+	rows, err := tx.QueryContext(ctx, lockQuery, pq.Array(productIDs))
+	if err != nil {
+		return 0, err
+	}
+	available := make(map[int64]int64, len(productIDs))
+	for rows.Next() {
+		var id, stock int64
+		if err := rows.Scan(&id, &stock); err != nil {
+			rows.Close()
+			return 0, err
 		}
+		available[id] = stock
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
 
-		itemQuery := "INSERT INTO order_items (order_id, product_id, quantity) VALUES ($1, $2, $3)"
-		_, err = tx.Exec(itemQuery, order.ID, item.ProductID, item.Quantity)
-		if err != nil {
-			return err
+	totals := make([]int64, len(productIDs))
+	for i, id := range productIDs {
+		stock, ok := available[id]
+		if !ok {
+			return 0, apperrors.NotFound("product")
+		}
+		if stock < requested[id] {
+			return 0, &apperrors.InsufficientStockError{ProductID: int(id), Available: int(stock), Requested: int(requested[id])}
 		}
+		totals[i] = requested[id]
 	}
 
-	return nil
+	result, err := tx.ExecContext(ctx, updateStockQuery, pq.Array(productIDs), pq.Array(totals))
+	if err != nil {
+		return 0, err
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return 0, err
+	} else if updated != int64(len(productIDs)) {
+		return 0, fmt.Errorf("reserved stock for %d of %d products", updated, len(productIDs))
+	}
+
+	var total float64
+	err = tx.QueryRowContext(ctx, itemsQuery, orderID, pq.Array(itemProductIDs), pq.Array(quantities)).Scan(&total)
+	return total, translateError(ctx, err, "order item")
 }
 
-func (or *orderRepository) GetByID(id int) (*models.Order, error) {
+func (or *orderRepository) GetByID(ctx context.Context, id int) (*models.Order, error) {
+	ctx, cancel := or.Timeouts.read(ctx)
+	defer cancel()
+
 	order := &models.Order{}
-	query := "SELECT id, customer_id, status, created_at FROM orders WHERE id = $1"
-	err := or.DB.QueryRow(query, id).Scan(&order.ID, &order.CustomerID, &order.Status, &order.CreatedAt)
+	var shipping, billing []byte
+	query := "SELECT id, customer_id, status, created_at, version, total, shipping_address, billing_address FROM orders WHERE id = $1"
+	ctx, span := startSpan(ctx, "OrderRepository.GetByID", query)
+	defer span.End()
+	err := or.DB.Primary.QueryRowContext(ctx, query, id).Scan(&order.ID, &order.CustomerID, &order.Status, &order.CreatedAt, &order.Version, &order.Total, &shipping, &billing)
 	if err != nil {
+		return nil, translateError(ctx, err, "order")
+	}
+	if err := unmarshalOrderAddresses(order, shipping, billing); err != nil {
 		return nil, err
 	}
 
-	itemsQuery := "SELECT id, product_id, quantity FROM order_items WHERE order_id = $1"
+	itemsQuery := "SELECT id, product_id, quantity, unit_price FROM order_items WHERE order_id = $1"
 
-	rows, err := or.DB.Query(itemsQuery, order.ID)
+	rows, err := or.DB.Primary.QueryContext(ctx, itemsQuery, order.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -91,50 +202,209 @@ func (or *orderRepository) GetByID(id int) (*models.Order, error) {
 	var orderItems []models.OrderItem
 	for rows.Next() {
 		var item models.OrderItem
-		err = rows.Scan(&item.ID, &item.ProductID, &item.Quantity)
+		err = rows.Scan(&item.ID, &item.ProductID, &item.Quantity, &item.UnitPrice)
 		if err != nil {
 			return nil, err
 		}
 		item.OrderID = order.ID
 		orderItems = append(orderItems, item)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	order.OrderItems = orderItems
 
 	return order, nil
 }
 
-func (or *orderRepository) GetOrdersByCustomerID(id int) ([]*models.Order, error) {
-	query := "SELECT id, customer_id, status, created_at FROM orders WHERE customer_id = $1"
-	rows, err := or.DB.Query(query, id)
+func (or *orderRepository) List(ctx context.Context, filter OrderFilter) ([]*models.Order, error) {
+	ctx, cancel := or.Timeouts.read(ctx)
+	defer cancel()
+
+	query, args := listOrdersQuery(filter)
+	ctx, span := startSpan(ctx, "OrderRepository.List", query)
+	defer span.End()
+
+	db := or.DB.Reader(ctx)
+	var orders []*models.Order
+	err := scanOrders(ctx, db, query, args, func(order *models.Order) error {
+		orders = append(orders, order)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	if filter.WithItems && len(orders) > 0 {
+		if err := or.loadItems(ctx, db, orders); err != nil {
+			return nil, err
+		}
+	}
+	return orders, nil
+}
+
+func (or *orderRepository) Stream(ctx context.Context, filter OrderFilter, fn func(order *models.Order) error) error {
+	query, args := listOrdersQuery(filter)
+	ctx, span := startSpan(ctx, "OrderRepository.Stream", query)
+	defer span.End()
+
+	return withStatementTimeout(ctx, or.DB.Reader(ctx), or.Timeouts.Export, func(db Querier) error {
+		return scanOrders(ctx, db, query, args, fn)
+	})
+}
+
+func listOrdersQuery(filter OrderFilter) (string, []any) {
+	var where whereBuilder
+	if filter.CustomerID != 0 {
+		where.add("customer_id = " + where.arg(filter.CustomerID))
+	}
+	if filter.ProductID != 0 {
+		where.add("EXISTS (SELECT 1 FROM order_items oi WHERE oi.order_id = o.id AND oi.product_id = " + where.arg(filter.ProductID) + ")")
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		where.add("status = ANY(" + where.arg(pq.Array(statuses)) + ")")
+	}
+	if !filter.CreatedFrom.IsZero() {
+		where.add("created_at >= " + where.arg(filter.CreatedFrom))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where.add("created_at < " + where.arg(filter.CreatedBefore))
+	}
+	if filter.MinTotal != nil {
+		where.add("total >= " + where.arg(*filter.MinTotal))
+	}
+	if filter.MaxTotal != nil {
+		where.add("total <= " + where.arg(*filter.MaxTotal))
+	}
+
+	column := "created_at"
+	var after any
+	if filter.After != nil {
+		after = filter.After.CreatedAt
+	}
+	if filter.Sort == OrderSortTotal {
+		column = "total"
+		if filter.After != nil {
+			after = filter.After.Total
+		}
+	}
+	direction, comparison := "DESC", "<"
+	if filter.Ascending {
+		direction, comparison = "ASC", ">"
+	}
+	if filter.After != nil {
+		where.add("(" + column + ", id) " + comparison + " (" + where.arg(after) + ", " + where.arg(filter.After.ID) + ")")
+	}
+
+	query := "SELECT id, customer_id, status, created_at, version, total, shipping_address, billing_address FROM orders o" +
+		where.String() + " ORDER BY " + column + " " + direction + ", id " + direction
+	if filter.Limit > 0 {
+		query += " LIMIT " + where.arg(filter.Limit)
+	}
+	return query, where.args
+}
+
+func scanOrders(ctx context.Context, db Querier, query string, args []any, fn func(order *models.Order) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	var orders []*models.Order
 	for rows.Next() {
 		var order = new(models.Order)
-		if err := rows.Scan(&order.ID, &order.CustomerID, &order.Status, &order.CreatedAt); err != nil {
-			return nil, err
+		var shipping, billing []byte
+		if err := rows.Scan(&order.ID, &order.CustomerID, &order.Status, &order.CreatedAt, &order.Version, &order.Total, &shipping, &billing); err != nil {
+			return err
+		}
+		if err := unmarshalOrderAddresses(order, shipping, billing); err != nil {
+			return err
+		}
+		if err := fn(order); err != nil {
+			return err
 		}
-		orders = append(orders, order)
 	}
+	return rows.Err()
+}
 
-	return orders, nil
+func marshalOrderAddress(address *models.OrderAddress) (any, error) {
+	if address == nil {
+		return nil, nil
+	}
+	return json.Marshal(address)
 }
 
-func (or *orderRepository) Update(order *models.Order) error {
-	query := "UPDATE orders SET status = $1 WHERE id = $2"
-	_, err := or.DB.Exec(query, order.Status, order.ID)
+func unmarshalOrderAddresses(order *models.Order, shipping, billing []byte) (err error) {
+	if order.ShippingAddress, err = unmarshalOrderAddress(shipping); err != nil {
+		return err
+	}
+	order.BillingAddress, err = unmarshalOrderAddress(billing)
 	return err
 }
 
-func (or *orderRepository) GetOwnerID(id int) (int, error) {
+func unmarshalOrderAddress(data []byte) (*models.OrderAddress, error) {
+	if data == nil {
+		return nil, nil
+	}
+	address := &models.OrderAddress{}
+	return address, json.Unmarshal(data, address)
+}
+
+func (or *orderRepository) loadItems(ctx context.Context, db Querier, orders []*models.Order) error {
+	query := "SELECT id, order_id, product_id, quantity, unit_price FROM order_items WHERE order_id = ANY($1) ORDER BY order_id, id"
+	ctx, span := startSpan(ctx, "OrderRepository.loadItems", query)
+	defer span.End()
+
+	byID := make(map[int]*models.Order, len(orders))
+	ids := make([]int64, len(orders))
+	for i, order := range orders {
+		byID[order.ID] = order
+		ids[i] = int64(order.ID)
+	}
+
+	rows, err := db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.OrderItem
+		if err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.UnitPrice); err != nil {
+			return err
+		}
+		order := byID[item.OrderID]
+		order.OrderItems = append(order.OrderItems, item)
+	}
+	return rows.Err()
+}
+
+func (or *orderRepository) Update(ctx context.Context, order *models.Order) error {
+	ctx, cancel := or.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "UPDATE orders SET status = $1, version = version + 1 WHERE id = $2 AND version = $3 RETURNING version"
+	ctx, span := startSpan(ctx, "OrderRepository.Update", query)
+	defer span.End()
+	err := or.DB.Primary.QueryRowContext(ctx, query, order.Status, order.ID, order.Version).Scan(&order.Version)
+	return translateVersionedError(ctx, or.DB.Primary, err, "orders", order.ID, "order")
+}
+
+func (or *orderRepository) GetOwnerID(ctx context.Context, id int) (int, error) {
+	ctx, cancel := or.Timeouts.read(ctx)
+	defer cancel()
+
 	var userID int
 	query := "SELECT c.user_id FROM orders o JOIN customers c ON c.id = o.customer_id WHERE o.id = $1"
-	err := or.DB.QueryRow(query, id).Scan(&userID)
+	ctx, span := startSpan(ctx, "OrderRepository.GetOwnerID", query)
+	defer span.End()
+	err := or.DB.Primary.QueryRowContext(ctx, query, id).Scan(&userID)
 	if err != nil {
-		return 0, err
+		return 0, translateError(ctx, err, "order")
 	}
 	return userID, err
 }
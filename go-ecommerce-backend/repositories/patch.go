@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func patchQuery(table string, columns []string, values map[string]any, id, version int) (string, []any, error) {
+	assignments := make([]string, 0, len(columns)+1)
+	args := make([]any, 0, len(columns)+2)
+	for _, column := range columns {
+		value, ok := values[column]
+		if !ok {
+			return "", nil, fmt.Errorf("column %q of %s cannot be patched", column, table)
+		}
+		args = append(args, value)
+		assignments = append(assignments, column+" = $"+strconv.Itoa(len(args)))
+	}
+	assignments = append(assignments, "version = version + 1")
+	args = append(args, id, version)
+
+	query := "UPDATE " + table + " SET " + strings.Join(assignments, ", ") +
+		" WHERE id = $" + strconv.Itoa(len(args)-1) + " AND version = $" + strconv.Itoa(len(args)) + " RETURNING version"
+	return query, args, nil
+}
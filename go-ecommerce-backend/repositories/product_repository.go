@@ -1,57 +1,118 @@
 package repositories
 
 import (
-	"database/sql"
+	"context"
 
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
 )
 
 type ProductRepository interface {
-	Create(product *models.Product) error
-	GetByID(id int) (*models.Product, error)
-	Update(product *models.Product) error
-	Delete(id int) error
-	GetAll() ([]*models.Product, error)
+	Create(ctx context.Context, product *models.Product) error
+	GetByID(ctx context.Context, id int) (*models.Product, error)
+	Update(ctx context.Context, product *models.Product) error
+	Patch(ctx context.Context, product *models.Product, columns []string) error
+	Delete(ctx context.Context, id int, version int) error
+	Restock(ctx context.Context, id int, quantity int) error
+	GetAll(ctx context.Context) ([]*models.Product, error)
 }
 
 type productRepository struct {
-	DB *sql.DB
+	DB       *Cluster
+	Timeouts Timeouts
 }
 
-func NewProductRepository(db *sql.DB) ProductRepository {
-	return &productRepository{DB: db}
+func NewProductRepository(db *Cluster, timeouts Timeouts) ProductRepository {
+	return &productRepository{DB: db, Timeouts: timeouts}
 }
 
-func (pr *productRepository) Create(product *models.Product) error {
-	query := "INSERT INTO products (name, description, category, price, stock) VALUES ($1, $2, $3, $4, $5) RETURNING id"
-	return pr.DB.QueryRow(query, product.Name, product.Description, product.Category, product.Price, product.Stock).Scan(&product.ID)
+func (pr *productRepository) Create(ctx context.Context, product *models.Product) error {
+	ctx, cancel := pr.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "INSERT INTO products (name, description, category, price, stock) VALUES ($1, $2, $3, $4, $5) RETURNING id, version"
+	ctx, span := startSpan(ctx, "ProductRepository.Create", query)
+	defer span.End()
+	err := pr.DB.Primary.QueryRowContext(ctx, query, product.Name, product.Description, product.Category, product.Price, product.Stock).Scan(&product.ID, &product.Version)
+	return translateError(ctx, err, "product")
 }
 
-func (pr *productRepository) GetByID(id int) (*models.Product, error) {
+func (pr *productRepository) GetByID(ctx context.Context, id int) (*models.Product, error) {
+	ctx, cancel := pr.Timeouts.read(ctx)
+	defer cancel()
+
 	product := &models.Product{}
-	query := "SELECT id, name, description, category, price, stock FROM products WHERE id = $1"
-	err := pr.DB.QueryRow(query, id).Scan(&product.ID, &product.Name, &product.Description, &product.Category, &product.Price, &product.Stock)
+	query := "SELECT id, name, description, category, price, stock, version FROM products WHERE id = $1"
+	ctx, span := startSpan(ctx, "ProductRepository.GetByID", query)
+	defer span.End()
+	err := pr.DB.Reader(ctx).QueryRowContext(ctx, query, id).Scan(&product.ID, &product.Name, &product.Description, &product.Category, &product.Price, &product.Stock, &product.Version)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err, "product")
 	}
 	return product, nil
 }
 
-func (pr *productRepository) Update(product *models.Product) error {
-	query := "UPDATE products SET name = $1, description = $2, category = $3, price = $4, stock = $5 WHERE id = $6"
-	_, err := pr.DB.Exec(query, product.Name, product.Description, product.Category, product.Price, product.Stock, product.ID)
-	return err
+func (pr *productRepository) Update(ctx context.Context, product *models.Product) error {
+	ctx, cancel := pr.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "UPDATE products SET name = $1, description = $2, category = $3, price = $4, stock = $5, version = version + 1 WHERE id = $6 AND version = $7 RETURNING version"
+	ctx, span := startSpan(ctx, "ProductRepository.Update", query)
+	defer span.End()
+	err := pr.DB.Primary.QueryRowContext(ctx, query, product.Name, product.Description, product.Category, product.Price, product.Stock, product.ID, product.Version).Scan(&product.Version)
+	return translateVersionedError(ctx, pr.DB.Primary, err, "products", product.ID, "product")
 }
 
-func (pr *productRepository) Delete(id int) error {
-	query := "DELETE FROM products WHERE id = $1"
-	_, err := pr.DB.Exec(query, id)
-	return err
+func (pr *productRepository) Patch(ctx context.Context, product *models.Product, columns []string) error {
+	ctx, cancel := pr.Timeouts.write(ctx)
+	defer cancel()
+
+	values := map[string]any{
+		"name":        product.Name,
+		"description": product.Description,
+		"category":    product.Category,
+		"price":       product.Price,
+		"stock":       product.Stock,
+	}
+	query, args, err := patchQuery("products", columns, values, product.ID, product.Version)
+	if err != nil {
+		return err
+	}
+	ctx, span := startSpan(ctx, "ProductRepository.Patch", query)
+	defer span.End()
+	err = pr.DB.Primary.QueryRowContext(ctx, query, args...).Scan(&product.Version)
+	return translateVersionedError(ctx, pr.DB.Primary, err, "products", product.ID, "product")
 }
 
-func (pr *productRepository) GetAll() ([]*models.Product, error) {
-	query := "SELECT id, name, description, category, price, stock FROM products"
-	rows, err := pr.DB.Query(query)
+func (pr *productRepository) Delete(ctx context.Context, id int, version int) error {
+	ctx, cancel := pr.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "DELETE FROM products WHERE id = $1 AND ($2 = 0 OR version = $2) RETURNING id"
+	ctx, span := startSpan(ctx, "ProductRepository.Delete", query)
+	defer span.End()
+	err := pr.DB.Primary.QueryRowContext(ctx, query, id, version).Scan(&id)
+	return translateVersionedError(ctx, pr.DB.Primary, err, "products", id, "product")
+}
+
+func (pr *productRepository) Restock(ctx context.Context, id int, quantity int) error {
+	ctx, cancel := pr.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "UPDATE products SET stock = stock + $1, version = version + 1 WHERE id = $2 RETURNING id"
+	ctx, span := startSpan(ctx, "ProductRepository.Restock", query)
+	defer span.End()
+	err := pr.DB.Primary.QueryRowContext(ctx, query, quantity, id).Scan(&id)
+	return translateError(ctx, err, "product")
+}
+
+func (pr *productRepository) GetAll(ctx context.Context) ([]*models.Product, error) {
+	ctx, cancel := pr.Timeouts.read(ctx)
+	defer cancel()
+
+	query := "SELECT id, name, description, category, price, stock, version FROM products"
+	ctx, span := startSpan(ctx, "ProductRepository.GetAll", query)
+	defer span.End()
+	rows, err := pr.DB.Reader(ctx).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +121,7 @@ func (pr *productRepository) GetAll() ([]*models.Product, error) {
 	var products []*models.Product
 	for rows.Next() {
 		var product = new(models.Product)
-		if err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Category, &product.Price, &product.Stock); err != nil {
+		if err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Category, &product.Price, &product.Stock, &product.Version); err != nil {
 			return nil, err
 		}
 		products = append(products, product)
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"strconv"
+	"strings"
+)
+
+type whereBuilder struct {
+	conditions []string
+	args       []any
+}
+
+func (w *whereBuilder) arg(value any) string {
+	w.args = append(w.args, value)
+	return "$" + strconv.Itoa(len(w.args))
+}
+
+func (w *whereBuilder) add(condition string) {
+	w.conditions = append(w.conditions, condition)
+}
+
+func (w *whereBuilder) String() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conditions, " AND ")
+}
@@ -0,0 +1,25 @@
+package repositories
+
+type Repositories struct {
+	Users       UserRepository
+	Customers   CustomerRepository
+	Addresses   AddressRepository
+	Products    ProductRepository
+	Orders      OrderRepository
+	Idempotency IdempotencyRepository
+	Cluster     *Cluster
+	Tx          TxManager
+}
+
+func NewSQLRepositories(cluster *Cluster, timeouts Timeouts) *Repositories {
+	return &Repositories{
+		Users:       NewUserRepository(cluster.Primary, timeouts),
+		Customers:   NewCustomerRepository(cluster.Primary, timeouts),
+		Addresses:   NewAddressRepository(cluster.Primary, timeouts),
+		Products:    NewProductRepository(cluster, timeouts),
+		Orders:      NewOrderRepository(cluster, timeouts),
+		Idempotency: NewIdempotencyRepository(cluster.Primary, timeouts),
+		Cluster:     cluster,
+		Tx:          NewTxManager(cluster.Primary, timeouts, DefaultTxOptions),
+	}
+}
@@ -0,0 +1,652 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+)
+
+type OpenFunc func(t *testing.T) *repositories.Repositories
+
+func RunContract(t *testing.T, open OpenFunc) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repos *repositories.Repositories)
+	}{
+		{"UserEmailsAreUnique", testUserEmailsAreUnique},
+		{"CustomersReferenceUsers", testCustomersReferenceUsers},
+		{"VersionsGuardWrites", testVersionsGuardWrites},
+		{"PatchUpdatesOnlyListedColumns", testPatchUpdatesOnlyListedColumns},
+		{"OrdersReserveStock", testOrdersReserveStock},
+		{"ReservingStockBumpsProductVersion", testReservingStockBumpsProductVersion},
+		{"FailedOrdersLeaveStockUntouched", testFailedOrdersLeaveStockUntouched},
+		{"ListOrders", testListOrders},
+		{"OrderTotals", testOrderTotals},
+		{"OrdersKeepAddressSnapshots", testOrdersKeepAddressSnapshots},
+		{"OwnerLookups", testOwnerLookups},
+		{"DeletingUserCascades", testDeletingUserCascades},
+		{"ReferencedRowsCannotBeDeleted", testReferencedRowsCannotBeDeleted},
+		{"IdempotencyKeys", testIdempotencyKeys},
+		{"RestockAddsStock", testRestockAddsStock},
+		{"TransactionsCommitTogether", testTransactionsCommitTogether},
+		{"TransactionsRollBackTogether", testTransactionsRollBackTogether},
+		{"NestedTransactionsRollBackAlone", testNestedTransactionsRollBackAlone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, open(t))
+		})
+	}
+}
+
+func createUser(t *testing.T, repos *repositories.Repositories, email string) *models.User {
+	user := &models.User{Email: email, Password: "hash", Role: models.RoleCustomer}
+	if err := repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func createCustomer(t *testing.T, repos *repositories.Repositories, email string) (*models.User, *models.Customer) {
+	user := createUser(t, repos, email)
+	customer := &models.Customer{UserID: user.ID, FirstName: "Ada", LastName: "Lovelace", PhoneNumber: "555-0100"}
+	if err := repos.Customers.Create(context.Background(), customer); err != nil {
+		t.Fatalf("create customer: %v", err)
+	}
+	return user, customer
+}
+
+func createProduct(t *testing.T, repos *repositories.Repositories, name string, stock int) *models.Product {
+	product := &models.Product{Name: name, Description: "A " + name, Category: "Test", Price: 9.99, Stock: stock}
+	if err := repos.Products.Create(context.Background(), product); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	return product
+}
+
+func createOrder(repos *repositories.Repositories, customerID int, items ...models.OrderItem) (*models.Order, error) {
+	order := &models.Order{CustomerID: customerID, Status: models.OrderStatusPending, OrderItems: items}
+	return order, repos.Orders.Create(context.Background(), order)
+}
+
+func stockOf(t *testing.T, repos *repositories.Repositories, id int) int {
+	product, err := repos.Products.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get product: %v", err)
+	}
+	return product.Stock
+}
+
+func testUserEmailsAreUnique(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos, "ada@example.com")
+	assert.NotZero(t, user.ID)
+	assert.Equal(t, 1, user.Version)
+	assert.False(t, user.CreatedAt.IsZero())
+
+	err := repos.Users.Create(ctx, &models.User{Email: "ada@example.com", Password: "hash", Role: models.RoleCustomer})
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+	assert.NotContains(t, err.Error(), "ada@example.com", "Conflicts must not expose the conflicting values")
+
+	other := createUser(t, repos, "grace@example.com")
+	other.Email = "ada@example.com"
+	assert.ErrorIs(t, repos.Users.Update(ctx, other), apperrors.ErrConflict)
+
+	found, err := repos.Users.GetByEmail(ctx, "ada@example.com")
+	if assert.NoError(t, err) {
+		assert.Equal(t, user.ID, found.ID)
+	}
+	_, err = repos.Users.GetByEmail(ctx, "nobody@example.com")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func testCustomersReferenceUsers(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	err := repos.Customers.Create(ctx, &models.Customer{UserID: 999999, FirstName: "No", LastName: "User"})
+	assert.ErrorIs(t, err, apperrors.ErrConflict, "A customer must reference an existing user")
+
+	user, customer := createCustomer(t, repos, "ada@example.com")
+	err = repos.Customers.Create(ctx, &models.Customer{UserID: user.ID, FirstName: "Second", LastName: "Profile"})
+	assert.ErrorIs(t, err, apperrors.ErrConflict, "A user can have only one customer")
+
+	found, err := repos.Customers.GetByUserID(ctx, user.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, customer.ID, found.ID)
+	}
+
+	err = repos.Addresses.Create(ctx, &models.Address{CustomerID: 999999, StreetAddress: "1 Main St", City: "London", Country: "UK"})
+	assert.ErrorIs(t, err, apperrors.ErrConflict, "An address must reference an existing customer")
+}
+
+func testVersionsGuardWrites(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	product := createProduct(t, repos, "Lamp", 10)
+	assert.Equal(t, 1, product.Version)
+
+	product.Price = 19.99
+	if !assert.NoError(t, repos.Products.Update(ctx, product)) {
+		return
+	}
+	assert.Equal(t, 2, product.Version)
+
+	stale := *product
+	stale.Version = 1
+	assert.ErrorIs(t, repos.Products.Update(ctx, &stale), apperrors.ErrPreconditionFailed)
+	assert.ErrorIs(t, repos.Products.Delete(ctx, product.ID, 1), apperrors.ErrPreconditionFailed)
+
+	missing := *product
+	missing.ID = 999999
+	assert.ErrorIs(t, repos.Products.Update(ctx, &missing), apperrors.ErrNotFound)
+	assert.ErrorIs(t, repos.Products.Delete(ctx, 999999, 0), apperrors.ErrNotFound)
+
+	assert.NoError(t, repos.Products.Delete(ctx, product.ID, 0), "Version 0 should delete unconditionally")
+	_, err := repos.Products.GetByID(ctx, product.ID)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func testPatchUpdatesOnlyListedColumns(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	product := createProduct(t, repos, "Lamp", 10)
+
+	patch := &models.Product{ID: product.ID, Name: "ignored", Stock: 4, Version: product.Version}
+	if !assert.NoError(t, repos.Products.Patch(ctx, patch, []string{"stock"})) {
+		return
+	}
+	assert.Equal(t, 2, patch.Version)
+
+	stored, err := repos.Products.GetByID(ctx, product.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Lamp", stored.Name)
+		assert.Equal(t, 4, stored.Stock)
+		assert.Equal(t, 2, stored.Version)
+	}
+
+	patch.Version = 1
+	assert.ErrorIs(t, repos.Products.Patch(ctx, patch, []string{"stock"}), apperrors.ErrPreconditionFailed)
+	patch.Version = 2
+	assert.Error(t, repos.Products.Patch(ctx, patch, []string{"id"}))
+}
+
+func testOrdersReserveStock(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	_, customer := createCustomer(t, repos, "ada@example.com")
+	lamp := createProduct(t, repos, "Lamp", 5)
+	desk := createProduct(t, repos, "Desk", 1)
+
+	order, err := createOrder(repos, customer.ID,
+		models.OrderItem{ProductID: lamp.ID, Quantity: 2},
+		models.OrderItem{ProductID: desk.ID, Quantity: 1},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotZero(t, order.ID)
+	assert.Equal(t, 1, order.Version)
+	assert.Equal(t, 3, stockOf(t, repos, lamp.ID))
+	assert.Equal(t, 0, stockOf(t, repos, desk.ID))
+
+	stored, err := repos.Orders.GetByID(ctx, order.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, customer.ID, stored.CustomerID)
+		assert.Equal(t, models.OrderStatusPending, stored.Status)
+		assert.Len(t, stored.OrderItems, 2)
+		for _, item := range stored.OrderItems {
+			assert.Equal(t, order.ID, item.OrderID)
+		}
+	}
+
+	orders, err := repos.Orders.List(ctx, repositories.OrderFilter{CustomerID: customer.ID})
+	if assert.NoError(t, err) && assert.Len(t, orders, 1) {
+		assert.Equal(t, order.ID, orders[0].ID)
+	}
+
+	stored.Status = models.OrderStatusCompleted
+	assert.NoError(t, repos.Orders.Update(ctx, stored))
+	stored.Version = 1
+	assert.ErrorIs(t, repos.Orders.Update(ctx, stored), apperrors.ErrPreconditionFailed)
+}
+
+func testReservingStockBumpsProductVersion(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	_, customer := createCustomer(t, repos, "ada@example.com")
+	lamp := createProduct(t, repos, "Lamp", 5)
+
+	if _, err := createOrder(repos, customer.ID, models.OrderItem{ProductID: lamp.ID, Quantity: 2}); !assert.NoError(t, err) {
+		return
+	}
+	reserved, err := repos.Products.GetByID(ctx, lamp.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Greater(t, reserved.Version, lamp.Version, "Reserving stock should change the product's version")
+
+	stale := *lamp
+	stale.Name = "Desk lamp"
+	assert.ErrorIs(t, repos.Products.Update(ctx, &stale), apperrors.ErrPreconditionFailed, "A write based on the pre-order version must not overwrite the reserved stock")
+	assert.Equal(t, 3, stockOf(t, repos, lamp.ID))
+}
+
+func testFailedOrdersLeaveStockUntouched(t *testing.T, repos *repositories.Repositories) {
+	_, customer := createCustomer(t, repos, "ada@example.com")
+	lamp := createProduct(t, repos, "Lamp", 5)
+	desk := createProduct(t, repos, "Desk", 1)
+
+	_, err := createOrder(repos, customer.ID,
+		models.OrderItem{ProductID: lamp.ID, Quantity: 2},
+		models.OrderItem{ProductID: desk.ID, Quantity: 2},
+	)
+	var stockErr *apperrors.InsufficientStockError
+	if assert.True(t, errors.As(err, &stockErr), "expected an InsufficientStockError, got %v", err) {
+		assert.Equal(t, desk.ID, stockErr.ProductID)
+		assert.Equal(t, 1, stockErr.Available)
+		assert.Equal(t, 2, stockErr.Requested)
+	}
+
+	_, err = createOrder(repos, customer.ID,
+		models.OrderItem{ProductID: lamp.ID, Quantity: 1},
+		models.OrderItem{ProductID: lamp.ID, Quantity: 1},
+	)
+	assert.ErrorIs(t, err, apperrors.ErrConflict, "A product can appear only once per order")
+
+	_, err = createOrder(repos, customer.ID,
+		models.OrderItem{ProductID: lamp.ID, Quantity: 1},
+		models.OrderItem{ProductID: 999999, Quantity: 1},
+	)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	_, err = createOrder(repos, 999999, models.OrderItem{ProductID: lamp.ID, Quantity: 1})
+	assert.ErrorIs(t, err, apperrors.ErrConflict, "An order must reference an existing customer")
+
+	assert.Equal(t, 5, stockOf(t, repos, lamp.ID), "Failed orders should not reserve any stock")
+	assert.Equal(t, 1, stockOf(t, repos, desk.ID))
+
+	orders, err := repos.Orders.List(context.Background(), repositories.OrderFilter{CustomerID: customer.ID})
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+}
+
+func testListOrders(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	_, customer := createCustomer(t, repos, "ada@example.com")
+	_, other := createCustomer(t, repos, "grace@example.com")
+	lamp := createProduct(t, repos, "Lamp", 10)
+	desk := createProduct(t, repos, "Desk", 10)
+
+	var placed []*models.Order
+	for range 3 {
+		order, err := createOrder(repos, customer.ID,
+			models.OrderItem{ProductID: lamp.ID, Quantity: 1},
+			models.OrderItem{ProductID: desk.ID, Quantity: 1},
+		)
+		if !assert.NoError(t, err) {
+			return
+		}
+		placed = append(placed, order)
+	}
+	if _, err := createOrder(repos, other.ID, models.OrderItem{ProductID: lamp.ID, Quantity: 1}); !assert.NoError(t, err) {
+		return
+	}
+	placed[1].Status = models.OrderStatusCompleted
+	if !assert.NoError(t, repos.Orders.Update(ctx, placed[1])) {
+		return
+	}
+
+	ids := func(orders []*models.Order) []int {
+		var ids []int
+		for _, order := range orders {
+			ids = append(ids, order.ID)
+		}
+		return ids
+	}
+
+	first, err := repos.Orders.List(ctx, repositories.OrderFilter{CustomerID: customer.ID, Limit: 2, WithItems: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []int{placed[2].ID, placed[1].ID}, ids(first), "Orders should be listed newest first")
+	for _, order := range first {
+		assert.Len(t, order.OrderItems, 2)
+	}
+
+	last := first[len(first)-1]
+	rest, err := repos.Orders.List(ctx, repositories.OrderFilter{
+		CustomerID: customer.ID,
+		Limit:      2,
+		After:      &repositories.OrderCursor{CreatedAt: last.CreatedAt, ID: last.ID},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{placed[0].ID}, ids(rest))
+		assert.Empty(t, rest[0].OrderItems, "Items are only loaded when requested")
+	}
+
+	ascending, err := repos.Orders.List(ctx, repositories.OrderFilter{CustomerID: customer.ID, Ascending: true})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{placed[0].ID, placed[1].ID, placed[2].ID}, ids(ascending))
+	}
+
+	completed, err := repos.Orders.List(ctx, repositories.OrderFilter{
+		CustomerID: customer.ID,
+		Statuses:   []models.OrderStatus{models.OrderStatusCompleted},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{placed[1].ID}, ids(completed))
+	}
+
+	createdAt := placed[0].CreatedAt
+	inRange, err := repos.Orders.List(ctx, repositories.OrderFilter{
+		CustomerID:    customer.ID,
+		CreatedFrom:   createdAt.Add(-time.Minute),
+		CreatedBefore: createdAt.Add(time.Minute),
+	})
+	if assert.NoError(t, err) {
+		assert.Len(t, inRange, 3)
+	}
+	future, err := repos.Orders.List(ctx, repositories.OrderFilter{CustomerID: customer.ID, CreatedFrom: createdAt.Add(time.Hour)})
+	if assert.NoError(t, err) {
+		assert.Empty(t, future)
+	}
+}
+
+func testOrderTotals(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	_, customer := createCustomer(t, repos, "ada@example.com")
+	lamp := createProduct(t, repos, "Lamp", 10)
+	desk := createProduct(t, repos, "Desk", 10)
+
+	small, err := createOrder(repos, customer.ID, models.OrderItem{ProductID: lamp.ID, Quantity: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	large, err := createOrder(repos, customer.ID,
+		models.OrderItem{ProductID: lamp.ID, Quantity: 3},
+		models.OrderItem{ProductID: desk.ID, Quantity: 2},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 9.99, small.Total)
+	assert.Equal(t, 49.95, large.Total)
+
+	repriced, err := repos.Products.GetByID(ctx, lamp.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	repriced.Price = 100
+	if !assert.NoError(t, repos.Products.Update(ctx, repriced)) {
+		return
+	}
+
+	stored, err := repos.Orders.GetByID(ctx, large.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 49.95, stored.Total, "A later price change must not rewrite past totals")
+		for _, item := range stored.OrderItems {
+			assert.Equal(t, 9.99, item.UnitPrice)
+		}
+	}
+
+	byTotal, err := repos.Orders.List(ctx, repositories.OrderFilter{Sort: repositories.OrderSortTotal, Limit: 1})
+	if assert.NoError(t, err) && assert.Len(t, byTotal, 1) {
+		assert.Equal(t, large.ID, byTotal[0].ID)
+		after := &repositories.OrderCursor{Total: byTotal[0].Total, ID: byTotal[0].ID}
+		rest, err := repos.Orders.List(ctx, repositories.OrderFilter{Sort: repositories.OrderSortTotal, After: after})
+		if assert.NoError(t, err) && assert.Len(t, rest, 1) {
+			assert.Equal(t, small.ID, rest[0].ID)
+		}
+	}
+
+	withDesk, err := repos.Orders.List(ctx, repositories.OrderFilter{ProductID: desk.ID})
+	if assert.NoError(t, err) && assert.Len(t, withDesk, 1) {
+		assert.Equal(t, large.ID, withDesk[0].ID)
+	}
+
+	minTotal, maxTotal := 9.99, 20.0
+	inRange, err := repos.Orders.List(ctx, repositories.OrderFilter{MinTotal: &minTotal, MaxTotal: &maxTotal})
+	if assert.NoError(t, err) && assert.Len(t, inRange, 1) {
+		assert.Equal(t, small.ID, inRange[0].ID, "Total bounds are inclusive")
+	}
+
+	var streamed []int
+	err = repos.Orders.Stream(ctx, repositories.OrderFilter{Ascending: true}, func(order *models.Order) error {
+		streamed = append(streamed, order.ID)
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{small.ID, large.ID}, streamed)
+	}
+	stop := errors.New("stop")
+	err = repos.Orders.Stream(ctx, repositories.OrderFilter{}, func(order *models.Order) error {
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+}
+
+func testOrdersKeepAddressSnapshots(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	_, customer := createCustomer(t, repos, "ada@example.com")
+	address := &models.Address{CustomerID: customer.ID, StreetAddress: "1 Main St", City: "London", Country: "UK"}
+	if err := repos.Addresses.Create(ctx, address); err != nil {
+		t.Fatalf("create address: %v", err)
+	}
+	lamp := createProduct(t, repos, "Lamp", 5)
+
+	order := &models.Order{
+		CustomerID:      customer.ID,
+		Status:          models.OrderStatusPending,
+		ShippingAddress: address.Snapshot(),
+		OrderItems:      []models.OrderItem{{ProductID: lamp.ID, Quantity: 1}},
+	}
+	if !assert.NoError(t, repos.Orders.Create(ctx, order)) {
+		return
+	}
+
+	address.StreetAddress = "2 High St"
+	if !assert.NoError(t, repos.Addresses.Update(ctx, address)) {
+		return
+	}
+	stored, err := repos.Orders.GetByID(ctx, order.ID)
+	if assert.NoError(t, err) && assert.NotNil(t, stored.ShippingAddress) {
+		assert.Equal(t, "1 Main St", stored.ShippingAddress.StreetAddress, "Editing the address should not rewrite the order")
+		assert.Equal(t, address.ID, stored.ShippingAddress.AddressID)
+		assert.Nil(t, stored.BillingAddress)
+	}
+
+	assert.NoError(t, repos.Addresses.Delete(ctx, address.ID, address.Version), "Orders should not block deleting an address")
+	orders, err := repos.Orders.List(ctx, repositories.OrderFilter{CustomerID: customer.ID})
+	if assert.NoError(t, err) && assert.Len(t, orders, 1) && assert.NotNil(t, orders[0].ShippingAddress) {
+		assert.Equal(t, "London", orders[0].ShippingAddress.City)
+	}
+}
+
+func testOwnerLookups(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	user, customer := createCustomer(t, repos, "ada@example.com")
+	address := &models.Address{CustomerID: customer.ID, StreetAddress: "1 Main St", City: "London", Country: "UK"}
+	if err := repos.Addresses.Create(ctx, address); err != nil {
+		t.Fatalf("create address: %v", err)
+	}
+	lamp := createProduct(t, repos, "Lamp", 5)
+	order, err := createOrder(repos, customer.ID, models.OrderItem{ProductID: lamp.ID, Quantity: 1})
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	owner, err := repos.Addresses.GetOwnerID(ctx, address.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, owner)
+
+	owner, err = repos.Orders.GetOwnerID(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, owner)
+
+	_, err = repos.Addresses.GetOwnerID(ctx, 999999)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	_, err = repos.Orders.GetOwnerID(ctx, 999999)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func testDeletingUserCascades(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	user, customer := createCustomer(t, repos, "ada@example.com")
+	address := &models.Address{CustomerID: customer.ID, StreetAddress: "1 Main St", City: "London", Country: "UK"}
+	if err := repos.Addresses.Create(ctx, address); err != nil {
+		t.Fatalf("create address: %v", err)
+	}
+
+	if !assert.NoError(t, repos.Users.Delete(ctx, user.ID, user.Version)) {
+		return
+	}
+	_, err := repos.Customers.GetByID(ctx, customer.ID)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound, "Deleting a user should delete their customer")
+	_, err = repos.Addresses.GetByID(ctx, address.ID)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound, "Deleting a customer should delete their addresses")
+}
+
+func testReferencedRowsCannotBeDeleted(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	user, customer := createCustomer(t, repos, "ada@example.com")
+	lamp := createProduct(t, repos, "Lamp", 5)
+	if _, err := createOrder(repos, customer.ID, models.OrderItem{ProductID: lamp.ID, Quantity: 1}); err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	assert.ErrorIs(t, repos.Users.Delete(ctx, user.ID, 0), apperrors.ErrConflict, "A user whose customer has orders cannot be deleted")
+	assert.ErrorIs(t, repos.Products.Delete(ctx, lamp.ID, 0), apperrors.ErrConflict, "An ordered product cannot be deleted")
+
+	_, err := repos.Users.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	_, err = repos.Customers.GetByID(ctx, customer.ID)
+	assert.NoError(t, err, "A failed delete should not cascade")
+}
+
+func testIdempotencyKeys(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	record := &models.IdempotencyRecord{UserID: 1, Key: "order-1", Fingerprint: strings.Repeat("a", 64)}
+
+	existing, err := repos.Idempotency.Reserve(ctx, record, time.Hour, time.Minute)
+	assert.NoError(t, err)
+	assert.Nil(t, existing, "A new key should be reserved")
+	assert.True(t, record.ExpiresAt.After(time.Now()))
+
+	existing, err = repos.Idempotency.Reserve(ctx, record, time.Hour, time.Minute)
+	if assert.NoError(t, err) && assert.NotNil(t, existing) {
+		assert.False(t, existing.Completed(), "The first request is still in flight")
+		assert.Equal(t, record.Fingerprint, existing.Fingerprint)
+	}
+
+	record.Status = http.StatusCreated
+	record.Headers = http.Header{"Content-Type": {"application/json"}}
+	record.Body = []byte(`{"id":1}`)
+	assert.NoError(t, repos.Idempotency.Complete(ctx, record))
+	assert.NoError(t, repos.Idempotency.Release(ctx, record), "Releasing a completed key should do nothing")
+
+	existing, err = repos.Idempotency.Reserve(ctx, record, time.Hour, time.Minute)
+	if assert.NoError(t, err) && assert.NotNil(t, existing) {
+		assert.True(t, existing.Completed())
+		assert.Equal(t, http.StatusCreated, existing.Status)
+		assert.Equal(t, "application/json", existing.Headers.Get("Content-Type"))
+		assert.Equal(t, `{"id":1}`, string(existing.Body))
+	}
+
+	released := &models.IdempotencyRecord{UserID: 1, Key: "order-2", Fingerprint: strings.Repeat("b", 64)}
+	_, err = repos.Idempotency.Reserve(ctx, released, time.Hour, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, repos.Idempotency.Release(ctx, released))
+	existing, err = repos.Idempotency.Reserve(ctx, released, time.Hour, time.Minute)
+	assert.NoError(t, err)
+	assert.Nil(t, existing, "A released key should be reserved again")
+
+	abandoned := &models.IdempotencyRecord{UserID: 3, Key: "order-1", Fingerprint: strings.Repeat("d", 64)}
+	_, err = repos.Idempotency.Reserve(ctx, abandoned, time.Hour, -time.Minute)
+	assert.NoError(t, err)
+	existing, err = repos.Idempotency.Reserve(ctx, abandoned, time.Hour, time.Minute)
+	assert.NoError(t, err)
+	assert.Nil(t, existing, "A reservation whose lease expired should be taken over")
+	assert.True(t, abandoned.LockedUntil.After(time.Now()))
+
+	expired := &models.IdempotencyRecord{UserID: 2, Key: "order-1", Fingerprint: strings.Repeat("c", 64)}
+	_, err = repos.Idempotency.Reserve(ctx, expired, -time.Minute, time.Minute)
+	assert.NoError(t, err)
+	deleted, err := repos.Idempotency.DeleteExpired(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func testRestockAddsStock(t *testing.T, repos *repositories.Repositories) {
+	product := createProduct(t, repos, "Lamp", 5)
+
+	assert.NoError(t, repos.Products.Restock(context.Background(), product.ID, 3))
+	assert.Equal(t, 8, stockOf(t, repos, product.ID))
+	stale := *product
+	assert.ErrorIs(t, repos.Products.Update(context.Background(), &stale), apperrors.ErrPreconditionFailed, "Restocking should change the product's version")
+	assert.ErrorIs(t, repos.Products.Restock(context.Background(), 999999, 1), apperrors.ErrNotFound)
+}
+
+func testTransactionsCommitTogether(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	var user *models.User
+	var customer *models.Customer
+	err := repos.Tx.WithinTx(ctx, func(tx *repositories.Repositories) error {
+		user, customer = createCustomer(t, tx, "ada@example.com")
+		found, err := tx.Customers.GetByUserID(ctx, user.ID)
+		if assert.NoError(t, err, "Reads inside the transaction should see its writes") {
+			assert.Equal(t, customer.ID, found.ID)
+		}
+		return nil
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = repos.Users.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	_, err = repos.Customers.GetByID(ctx, customer.ID)
+	assert.NoError(t, err)
+}
+
+func testTransactionsRollBackTogether(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	product := createProduct(t, repos, "Lamp", 5)
+
+	err := repos.Tx.WithinTx(ctx, func(tx *repositories.Repositories) error {
+		createUser(t, tx, "ada@example.com")
+		if err := tx.Products.Restock(ctx, product.ID, 10); err != nil {
+			return err
+		}
+		return tx.Customers.Create(ctx, &models.Customer{UserID: 999999, FirstName: "No", LastName: "User"})
+	})
+
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+	_, err = repos.Users.GetByEmail(ctx, "ada@example.com")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound, "The user should be rolled back with the failed customer")
+	assert.Equal(t, 5, stockOf(t, repos, product.ID))
+}
+
+func testNestedTransactionsRollBackAlone(t *testing.T, repos *repositories.Repositories) {
+	ctx := context.Background()
+	errInner := errors.New("inner failed")
+
+	err := repos.Tx.WithinTx(ctx, func(tx *repositories.Repositories) error {
+		createUser(t, tx, "ada@example.com")
+		err := tx.Tx.WithinTx(ctx, func(inner *repositories.Repositories) error {
+			createUser(t, inner, "grace@example.com")
+			return errInner
+		})
+		assert.ErrorIs(t, err, errInner)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	_, err = repos.Users.GetByEmail(ctx, "ada@example.com")
+	assert.NoError(t, err)
+	_, err = repos.Users.GetByEmail(ctx, "grace@example.com")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound, "Only the nested transaction should be rolled back")
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+type Timeouts struct {
+	Read   time.Duration
+	Write  time.Duration
+	Export time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Read:  5 * time.Second,
+	Write: 10 * time.Second,
+}
+
+func (t Timeouts) read(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Read)
+}
+
+func (t Timeouts) write(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Write)
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func withStatementTimeout(ctx context.Context, db Querier, timeout time.Duration, fn func(db Querier) error) error {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := beginner.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('statement_timeout', $1, true)", strconv.FormatInt(timeout.Milliseconds(), 10)); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories")
+
+func startSpan(ctx context.Context, operation, query string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{attribute.String("db.system", "postgresql")}
+	if query != "" {
+		attrs = append(attrs, attribute.String("db.statement", query))
+	}
+	return tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
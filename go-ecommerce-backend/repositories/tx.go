@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	pqSerializationFailure = "40001"
+	pqDeadlockDetected     = "40P01"
+)
+
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(repos *Repositories) error) error
+}
+
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type TxOptions struct {
+	Isolation   sql.IsolationLevel
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+var DefaultTxOptions = TxOptions{
+	Isolation:   sql.LevelSerializable,
+	MaxAttempts: 3,
+	Backoff:     20 * time.Millisecond,
+}
+
+type sqlTxManager struct {
+	db       Querier
+	timeouts Timeouts
+	opts     TxOptions
+}
+
+func NewTxManager(db Querier, timeouts Timeouts, opts TxOptions) TxManager {
+	return &sqlTxManager{db: db, timeouts: timeouts, opts: opts}
+}
+
+func (m *sqlTxManager) WithinTx(ctx context.Context, fn func(repos *Repositories) error) error {
+	return runTx(ctx, m.db, m.opts, func(tx Querier) error {
+		return fn(NewSQLRepositories(PrimaryOnly(tx), m.timeouts))
+	})
+}
+
+func runTx(ctx context.Context, db Querier, opts TxOptions, fn func(tx Querier) error) error {
+	run := func() error {
+		return withinTx(ctx, db, &sql.TxOptions{Isolation: opts.Isolation}, fn)
+	}
+	if _, ok := db.(txBeginner); !ok {
+		return run()
+	}
+	return retryTx(ctx, opts, run)
+}
+
+var savepoints atomic.Int64
+
+func withinTx(ctx context.Context, db Querier, opts *sql.TxOptions, fn func(tx Querier) error) (err error) {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return withinSavepoint(ctx, db, fn)
+	}
+
+	tx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+			slog.DebugContext(ctx, "transaction rolled back", "error", err)
+			return
+		}
+		err = tx.Commit()
+	}()
+	return fn(tx)
+}
+
+func withinSavepoint(ctx context.Context, tx Querier, fn func(tx Querier) error) (err error) {
+	name := fmt.Sprintf("sp_%d", savepoints.Add(1))
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+			return
+		}
+		_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	}()
+	return fn(tx)
+}
+
+func retryTx(ctx context.Context, opts TxOptions, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsRetryable(err) || attempt >= opts.MaxAttempts {
+			return err
+		}
+
+		delay := opts.Backoff * time.Duration(attempt)
+		delay = delay/2 + rand.N(delay/2+1)
+		slog.DebugContext(ctx, "retrying transaction", "attempt", attempt, "retry_in", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == pqSerializationFailure || pqErr.Code == pqDeadlockDetected
+}
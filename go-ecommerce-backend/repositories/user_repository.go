@@ -1,60 +1,87 @@
 package repositories
 
 import (
-	"database/sql"
+	"context"
 
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
 )
 
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByID(id int) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	Update(user *models.User) error
-	Delete(id int) error
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id int, version int) error
 }
 
 type userRepository struct {
-	DB *sql.DB
+	DB       Querier
+	Timeouts Timeouts
 }
 
-func NewUserRepository(db *sql.DB) UserRepository {
-	return &userRepository{DB: db}
+func NewUserRepository(db Querier, timeouts Timeouts) UserRepository {
+	return &userRepository{DB: db, Timeouts: timeouts}
 }
 
-func (ur *userRepository) Create(user *models.User) error {
-	query := "INSERT INTO users (email, password, role) VALUES ($1, $2, $3) RETURNING id"
-	return ur.DB.QueryRow(query, user.Email, user.Password, user.Role).Scan(&user.ID)
+func (ur *userRepository) Create(ctx context.Context, user *models.User) error {
+	ctx, cancel := ur.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "INSERT INTO users (email, password, role) VALUES ($1, $2, $3) RETURNING id, version"
+	ctx, span := startSpan(ctx, "UserRepository.Create", query)
+	defer span.End()
+	err := ur.DB.QueryRowContext(ctx, query, user.Email, user.Password, user.Role).Scan(&user.ID, &user.Version)
+	return translateError(ctx, err, "user")
 }
 
-func (ur *userRepository) GetByID(id int) (*models.User, error) {
+func (ur *userRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, cancel := ur.Timeouts.read(ctx)
+	defer cancel()
+
 	user := &models.User{}
-	query := "SELECT id, email, password, role, created_at FROM users WHERE id = $1"
-	err := ur.DB.QueryRow(query, id).Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.CreatedAt)
+	query := "SELECT id, email, password, role, created_at, version FROM users WHERE id = $1"
+	ctx, span := startSpan(ctx, "UserRepository.GetByID", query)
+	defer span.End()
+	err := ur.DB.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.Version)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err, "user")
 	}
 	return user, nil
 }
 
-func (ur *userRepository) GetByEmail(email string) (*models.User, error) {
+func (ur *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := ur.Timeouts.read(ctx)
+	defer cancel()
+
 	user := &models.User{}
-	query := "SELECT id, email, password, role, created_at FROM users WHERE email = $1"
-	err := ur.DB.QueryRow(query, email).Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.CreatedAt)
+	query := "SELECT id, email, password, role, created_at, version FROM users WHERE email = $1"
+	ctx, span := startSpan(ctx, "UserRepository.GetByEmail", query)
+	defer span.End()
+	err := ur.DB.QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.Version)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err, "user")
 	}
 	return user, nil
 }
 
-func (ur *userRepository) Update(user *models.User) error {
-	query := "UPDATE users SET email = $1, password = $2, role = $3 WHERE id = $4"
-	_, err := ur.DB.Exec(query, user.Email, user.Password, user.Role, user.ID)
-	return err
+func (ur *userRepository) Update(ctx context.Context, user *models.User) error {
+	ctx, cancel := ur.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "UPDATE users SET email = $1, password = $2, role = $3, version = version + 1 WHERE id = $4 AND version = $5 RETURNING version"
+	ctx, span := startSpan(ctx, "UserRepository.Update", query)
+	defer span.End()
+	err := ur.DB.QueryRowContext(ctx, query, user.Email, user.Password, user.Role, user.ID, user.Version).Scan(&user.Version)
+	return translateVersionedError(ctx, ur.DB, err, "users", user.ID, "user")
 }
 
-func (ur *userRepository) Delete(id int) error {
-	query := "DELETE FROM users WHERE id = $1"
-	_, err := ur.DB.Exec(query, id)
-	return err
+func (ur *userRepository) Delete(ctx context.Context, id int, version int) error {
+	ctx, cancel := ur.Timeouts.write(ctx)
+	defer cancel()
+
+	query := "DELETE FROM users WHERE id = $1 AND ($2 = 0 OR version = $2) RETURNING id"
+	ctx, span := startSpan(ctx, "UserRepository.Delete", query)
+	defer span.End()
+	err := ur.DB.QueryRowContext(ctx, query, id, version).Scan(&id)
+	return translateVersionedError(ctx, ur.DB, err, "users", id, "user")
 }
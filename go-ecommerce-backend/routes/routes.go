@@ -1,70 +1,114 @@
 package routes
 
 import (
-	"database/sql"
+	"log/slog"
+	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/controllers"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/health"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/metrics"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/openapi"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/ratelimit"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
 )
 
-func SetupRoutes(db *sql.DB, cfg *config.Config) *mux.Router {
+func SetupRoutes(repos *repositories.Repositories, cfg *config.Config, logger *slog.Logger, checker *health.Checker, limiter ratelimit.Store) *mux.Router {
 	router := mux.NewRouter()
-
-	ctrls := controllers.NewControllers(db, cfg)
-
-	setupPublicRoutes(router, ctrls.AuthController, ctrls.ProductController)
-
-	v1 := router.PathPrefix("/v1").Subrouter()
-	v1.Use(middlewares.JWTAuthMiddleware(cfg.JWTSecret))
+	router.Use(middlewares.RequestIDMiddleware, middlewares.TracingMiddleware, middlewares.RequestLoggingMiddleware(logger), middlewares.MetricsMiddleware, middlewares.BodyLimitMiddleware(cfg.Server.MaxBodyBytes))
+	if repos.Cluster != nil && repos.Cluster.HasReplica() {
+		router.Use(middlewares.ReadYourWritesMiddleware(repos.Cluster, cfg.Auth.JWT()))
+	}
+	router.NotFoundHandler = unmatchedHandler(logger, http.StatusNotFound, problems.CodeNotFound, "Route not found")
+	router.MethodNotAllowedHandler = unmatchedHandler(logger, http.StatusMethodNotAllowed, problems.CodeMethodNotAllowed, "Method not allowed")
+
+	ctrls := controllers.NewControllers(repos, cfg)
+	idempotent := middlewares.IdempotencyMiddleware(repos.Idempotency, cfg.Idempotency.TTL, cfg.Idempotency.Lease)
+
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	setupHealthRoutes(router, controllers.NewHealthController(checker))
+	router.HandleFunc("/openapi.json", openapi.Handler(APISpec())).Methods("GET")
+	router.HandleFunc("/docs", openapi.SwaggerUIHandler("/openapi.json", "/docs/")).Methods("GET")
+	swaggerAssets := http.StripPrefix("/docs/", openapi.SwaggerAssetsHandler())
+	router.Handle("/docs/swagger-ui.css", swaggerAssets).Methods("GET")
+	router.Handle("/docs/swagger-ui-bundle.js", swaggerAssets).Methods("GET")
+
+	api := router.PathPrefix("/v1").Subrouter()
+	if cfg.RateLimit.Enabled && limiter != nil {
+		api.Use(middlewares.RateLimitMiddleware(cfg.RateLimit, limiter, cfg.Auth.JWT()))
+	}
+	setupPublicRoutes(api, ctrls.AuthController, ctrls.ProductController)
+
+	v1 := api.NewRoute().Subrouter()
+	v1.Use(middlewares.JWTAuthMiddleware(cfg.Auth.JWT()), middlewares.IfMatchMiddleware(cfg.Features.RequireIfMatch))
 
 	setupUserRoutes(v1, ctrls.UserController, ctrls.CustomerController)
-	setupCustomerRoutes(v1, ctrls.CustomerController, ctrls.AddressController, ctrls.OrderController)
-	setupAddressRoutes(v1, ctrls.AddressController)
-	setupProductRoutes(v1, ctrls.ProductController)
-	setupOrderRoutes(v1, ctrls.OrderController)
+	setupCustomerRoutes(v1, idempotent, ctrls.CustomerController, ctrls.AddressController, ctrls.OrderController)
+	setupAddressRoutes(v1, idempotent, ctrls.AddressController)
+	setupProductRoutes(v1, idempotent, ctrls.ProductController)
+	setupOrderRoutes(v1, idempotent, ctrls.OrderController)
+	setupAdminRoutes(v1, ctrls.OrderController)
 
 	return router
 }
 
-func setupOrderRoutes(v1 *mux.Router, orderController *controllers.OrderController) {
+func setupHealthRoutes(router *mux.Router, healthController *controllers.HealthController) {
+	router.HandleFunc("/healthz", healthController.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", healthController.Readiness).Methods("GET")
+}
+
+func setupOrderRoutes(v1 *mux.Router, idempotent func(http.HandlerFunc) http.HandlerFunc, orderController *controllers.OrderController) {
 	ordersRoutes := v1.PathPrefix("/orders").Subrouter()
 	ordersRoutes.Use(middlewares.OwnerOnlyMiddleware("id", orderController.OrderService.GetOwnerID))
 
 	ordersRoutes.HandleFunc("/{id:[0-9]+}", orderController.GetOrder).Methods("GET")
-	v1.HandleFunc("/orders", middlewares.ValidateBody(orderController.CreateOrder)).Methods("POST")
+	v1.HandleFunc("/orders", idempotent(middlewares.ValidateBody(orderController.CreateOrder))).Methods("POST")
 	ordersRoutes.HandleFunc("/{id:[0-9]+}", middlewares.ValidateBody(orderController.UpdateOrder)).Methods("PUT")
 }
 
-func setupProductRoutes(v1 *mux.Router, productController *controllers.ProductController) {
+func setupAdminRoutes(v1 *mux.Router, orderController *controllers.OrderController) {
+	adminRoutes := v1.PathPrefix("/admin").Subrouter()
+	adminRoutes.Use(middlewares.RoleAuthorizationMiddleware(string(models.RoleAdmin)))
+
+	adminRoutes.HandleFunc("/orders", orderController.ListOrders).Methods("GET")
+	adminRoutes.HandleFunc("/orders/export", orderController.ExportOrders).Methods("GET")
+	adminRoutes.HandleFunc("/orders/status", middlewares.ValidateBody(orderController.UpdateOrderStatuses)).Methods("POST")
+}
+
+func setupProductRoutes(v1 *mux.Router, idempotent func(http.HandlerFunc) http.HandlerFunc, productController *controllers.ProductController) {
 	productRoutes := v1.PathPrefix("/products").Subrouter()
 	productRoutes.Use(middlewares.RoleAuthorizationMiddleware(string(models.RoleAdmin)))
 
-	v1.HandleFunc("/products/{id:[0-9]+}", productController.GetProduct).Methods("GET")
-	productRoutes.HandleFunc("", middlewares.ValidateBody(productController.CreateProduct)).Methods("POST")
+	v1.HandleFunc("/products/{id:[0-9]+}", middlewares.ConditionalGet(productController.GetProduct)).Methods("GET")
+	productRoutes.HandleFunc("", idempotent(middlewares.ValidateBody(productController.CreateProduct))).Methods("POST")
 	productRoutes.HandleFunc("/{id:[0-9]+}", middlewares.ValidateBody(productController.UpdateProduct)).Methods("PUT")
+	productRoutes.HandleFunc("/{id:[0-9]+}", middlewares.MergePatchBody(productController.PatchProduct)).Methods("PATCH")
 	productRoutes.HandleFunc("/{id:[0-9]+}", productController.DeleteProduct).Methods("DELETE")
 }
 
-func setupAddressRoutes(v1 *mux.Router, addressController *controllers.AddressController) {
+func setupAddressRoutes(v1 *mux.Router, idempotent func(http.HandlerFunc) http.HandlerFunc, addressController *controllers.AddressController) {
 	addressRoutes := v1.PathPrefix("/addresses").Subrouter()
 	addressRoutes.Use(middlewares.OwnerOnlyMiddleware("id", addressController.AddressService.GetOwnerID))
 
 	addressRoutes.HandleFunc("/{id:[0-9]+}", addressController.GetAddress).Methods("GET")
-	v1.HandleFunc("/addresses", middlewares.ValidateBody(addressController.CreateAddress)).Methods("POST")
+	v1.HandleFunc("/addresses", idempotent(middlewares.ValidateBody(addressController.CreateAddress))).Methods("POST")
 	addressRoutes.HandleFunc("/{id:[0-9]+}", middlewares.ValidateBody(addressController.UpdateAddress)).Methods("PUT")
+	addressRoutes.HandleFunc("/{id:[0-9]+}", middlewares.MergePatchBody(addressController.PatchAddress)).Methods("PATCH")
 	addressRoutes.HandleFunc("/{id:[0-9]+}", addressController.DeleteAddress).Methods("DELETE")
 }
 
-func setupCustomerRoutes(v1 *mux.Router, customerController *controllers.CustomerController, addressController *controllers.AddressController, orderController *controllers.OrderController) {
+func setupCustomerRoutes(v1 *mux.Router, idempotent func(http.HandlerFunc) http.HandlerFunc, customerController *controllers.CustomerController, addressController *controllers.AddressController, orderController *controllers.OrderController) {
 	customerRoutes := v1.PathPrefix("/customers").Subrouter()
 	customerRoutes.Use(middlewares.OwnerOnlyMiddleware("id", customerController.CustomerService.GetOwnerID))
 
 	customerRoutes.HandleFunc("/{id:[0-9]+}", customerController.GetCustomer).Methods("GET")
-	v1.HandleFunc("/customers", middlewares.ValidateBody(customerController.CreateCustomer)).Methods("POST")
+	v1.HandleFunc("/customers", idempotent(middlewares.ValidateBody(customerController.CreateCustomer))).Methods("POST")
 	customerRoutes.HandleFunc("/{id:[0-9]+}", middlewares.ValidateBody(customerController.UpdateCustomer)).Methods("PUT")
+	customerRoutes.HandleFunc("/{id:[0-9]+}", middlewares.MergePatchBody(customerController.PatchCustomer)).Methods("PATCH")
 	customerRoutes.HandleFunc("/{id:[0-9]+}", customerController.DeleteCustomer).Methods("DELETE")
 
 	customerRoutes.HandleFunc("/{id:[0-9]+}/addresses", addressController.GetAddressesByCustomerID).Methods("GET")
@@ -83,8 +127,15 @@ func setupUserRoutes(v1 *mux.Router, userController *controllers.UserController,
 	userRoutes.HandleFunc("/{id:[0-9]+}/customer", customerController.GetCustomerByUserID).Methods("GET")
 }
 
-func setupPublicRoutes(router *mux.Router, authController *controllers.AuthController, productsController *controllers.ProductController) {
-	router.HandleFunc("/v1/login", middlewares.ValidateBody(authController.Login)).Methods("POST")
-	router.HandleFunc("/v1/register", middlewares.ValidateBody(authController.Register)).Methods("POST")
-	router.HandleFunc("/v1/products", productsController.GetAllProducts).Methods("GET")
+func setupPublicRoutes(v1 *mux.Router, authController *controllers.AuthController, productsController *controllers.ProductController) {
+	v1.HandleFunc("/login", middlewares.ValidateBody(authController.Login)).Methods("POST")
+	v1.HandleFunc("/register", middlewares.ValidateBody(authController.Register)).Methods("POST")
+	v1.HandleFunc("/products", middlewares.ConditionalGet(productsController.GetAllProducts)).Methods("GET")
+}
+
+func unmatchedHandler(logger *slog.Logger, status int, code problems.Code, detail string) http.Handler {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problems.Write(w, r, status, code, detail)
+	})
+	return middlewares.RequestIDMiddleware(middlewares.TracingMiddleware(middlewares.RequestLoggingMiddleware(logger)(middlewares.MetricsMiddleware(handler))))
 }
@@ -0,0 +1,132 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/controllers"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/health"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/mergepatch"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/openapi"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+)
+
+type loginResponse struct {
+	User  models.User `json:"user"`
+	Token string      `json:"token"`
+}
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+const idPath = "/{id:[0-9]+}"
+
+var ifMatch = []openapi.Parameter{{
+	Name:        "If-Match",
+	In:          "header",
+	Description: "Entity tag from a previous response. A stale tag is rejected with 412.",
+	Schema:      &openapi.Schema{Type: "string"},
+}}
+
+var ifNoneMatch = []openapi.Parameter{{
+	Name:        "If-None-Match",
+	In:          "header",
+	Description: "Entity tag from a previous response. A matching tag returns 304.",
+	Schema:      &openapi.Schema{Type: "string"},
+}}
+
+var maxIdempotencyKeyLength = middlewares.MaxIdempotencyKeyLength
+
+var idempotencyKey = []openapi.Parameter{{
+	Name:        "Idempotency-Key",
+	In:          "header",
+	Description: "Client-generated key. Retrying with the same key and body replays the stored response.",
+	Schema:      &openapi.Schema{Type: "string", MaxLength: &maxIdempotencyKeyLength},
+}}
+
+var maxOrderPageSize = float64(services.MaxOrderPageSize)
+var minPageSize = float64(1)
+
+var orderFilterQuery = []openapi.Parameter{
+	{Name: "status", In: "query", Description: "Comma-separated statuses to include.", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "product_id", In: "query", Description: "Only orders containing this product.", Schema: &openapi.Schema{Type: "integer"}},
+	{Name: "created_after", In: "query", Description: "Only orders created at or after this RFC 3339 time.", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+	{Name: "created_before", In: "query", Description: "Only orders created before this RFC 3339 time.", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+	{Name: "min_total", In: "query", Description: "Only orders with at least this total.", Schema: &openapi.Schema{Type: "number"}},
+	{Name: "max_total", In: "query", Description: "Only orders with at most this total.", Schema: &openapi.Schema{Type: "number"}},
+	{Name: "sort", In: "query", Description: "Sort field, prefixed with - for descending. Defaults to -created_at.", Schema: &openapi.Schema{Type: "string", Enum: []any{"-created_at", "created_at", "-total", "total"}}},
+}
+
+var orderPageQuery = []openapi.Parameter{
+	{Name: "limit", In: "query", Description: "Page size, 20 by default.", Schema: &openapi.Schema{Type: "integer", Minimum: &minPageSize, Maximum: &maxOrderPageSize}},
+	{Name: "cursor", In: "query", Description: "Opaque cursor from the Link header of the previous page.", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "view", In: "query", Description: "summary omits order items.", Schema: &openapi.Schema{Type: "string", Enum: []any{"full", "summary"}}},
+}
+
+var customerIDQuery = openapi.Parameter{Name: "customer_id", In: "query", Description: "Only orders of this customer.", Schema: &openapi.Schema{Type: "integer"}}
+
+var exportFormatQuery = openapi.Parameter{Name: "format", In: "query", Description: "csv (default) or ndjson.", Schema: &openapi.Schema{Type: "string", Enum: []any{"csv", "ndjson"}}}
+
+func queryParams(groups ...[]openapi.Parameter) []openapi.Parameter {
+	var params []openapi.Parameter
+	for _, group := range groups {
+		params = append(params, group...)
+	}
+	return params
+}
+
+func APISpec() *openapi.Document {
+	return openapi.Build(openapi.Info{Title: "Go E-commerce API", Version: "1.0.0"}, APIOperations())
+}
+
+func APIOperations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: "GET", Path: "/healthz", Summary: "Liveness probe", Tags: []string{"operations"}, Response: map[string]string{}},
+		{Method: "GET", Path: "/readyz", Summary: "Readiness probe", Tags: []string{"operations"}, Response: health.Report{}},
+		{Method: "GET", Path: "/metrics", Summary: "Prometheus metrics", Tags: []string{"operations"}, Response: "", ContentType: "text/plain"},
+		{Method: "GET", Path: "/openapi.json", Summary: "OpenAPI document", Tags: []string{"operations"}, Response: map[string]any{}},
+		{Method: "GET", Path: "/docs", Summary: "Swagger UI", Tags: []string{"operations"}, Response: "", ContentType: "text/html"},
+		{Method: "GET", Path: "/docs/swagger-ui.css", Summary: "Swagger UI stylesheet", Tags: []string{"operations"}, Response: "", ContentType: "text/css"},
+		{Method: "GET", Path: "/docs/swagger-ui-bundle.js", Summary: "Swagger UI script", Tags: []string{"operations"}, Response: "", ContentType: "text/javascript"},
+
+		{Method: "POST", Path: "/v1/login", Summary: "Authenticate and return a JWT", Tags: []string{"auth"}, Request: services.LoginRequest{}, Response: loginResponse{}},
+		{Method: "POST", Path: "/v1/register", Summary: "Register a new user", Tags: []string{"auth"}, Request: services.RegisterRequest{}, Response: messageResponse{}, Status: http.StatusCreated},
+
+		{Method: "GET", Path: "/v1/users" + idPath, Summary: "Get a user", Tags: []string{"users"}, Secured: true, Response: models.User{}},
+		{Method: "PUT", Path: "/v1/users" + idPath, Summary: "Update a user", Tags: []string{"users"}, Secured: true, Headers: ifMatch, Request: services.UserRequest{}, Response: models.User{}},
+		{Method: "PATCH", Path: "/v1/users" + idPath + "/password", Summary: "Change a user's password", Tags: []string{"users"}, Secured: true, Headers: ifMatch, Request: services.UserRequest{}, Response: models.User{}},
+		{Method: "DELETE", Path: "/v1/users" + idPath, Summary: "Delete a user", Tags: []string{"users"}, Secured: true, Headers: ifMatch, Status: http.StatusNoContent},
+		{Method: "GET", Path: "/v1/users" + idPath + "/customer", Summary: "Get a user's customer profile", Tags: []string{"users"}, Secured: true, Response: models.Customer{}},
+
+		{Method: "GET", Path: "/v1/customers" + idPath, Summary: "Get a customer", Tags: []string{"customers"}, Secured: true, Response: models.Customer{}},
+		{Method: "POST", Path: "/v1/customers", Summary: "Create a customer", Tags: []string{"customers"}, Secured: true, Headers: idempotencyKey, Request: services.CustomerRequest{}, Response: models.Customer{}, Status: http.StatusCreated},
+		{Method: "PUT", Path: "/v1/customers" + idPath, Summary: "Update a customer", Tags: []string{"customers"}, Secured: true, Headers: ifMatch, Request: services.CustomerRequest{}, Response: models.Customer{}},
+		{Method: "PATCH", Path: "/v1/customers" + idPath, Summary: "Partially update a customer", Tags: []string{"customers"}, Secured: true, Headers: ifMatch, Request: map[string]any{}, RequestType: mergepatch.ContentType, Response: models.Customer{}},
+		{Method: "DELETE", Path: "/v1/customers" + idPath, Summary: "Delete a customer", Tags: []string{"customers"}, Secured: true, Headers: ifMatch, Status: http.StatusNoContent},
+		{Method: "GET", Path: "/v1/customers" + idPath + "/addresses", Summary: "List a customer's addresses", Tags: []string{"customers"}, Secured: true, Response: []models.Address{}},
+		{Method: "GET", Path: "/v1/customers" + idPath + "/orders", Summary: "List a customer's orders", Tags: []string{"customers"}, Secured: true, Query: queryParams(orderFilterQuery, orderPageQuery), Response: []models.Order{}},
+
+		{Method: "GET", Path: "/v1/addresses" + idPath, Summary: "Get an address", Tags: []string{"addresses"}, Secured: true, Response: models.Address{}},
+		{Method: "POST", Path: "/v1/addresses", Summary: "Create an address", Tags: []string{"addresses"}, Secured: true, Headers: idempotencyKey, Request: services.AddressRequest{}, Response: models.Address{}, Status: http.StatusCreated},
+		{Method: "PUT", Path: "/v1/addresses" + idPath, Summary: "Update an address", Tags: []string{"addresses"}, Secured: true, Headers: ifMatch, Request: services.AddressRequest{}, Response: models.Address{}},
+		{Method: "PATCH", Path: "/v1/addresses" + idPath, Summary: "Partially update an address", Tags: []string{"addresses"}, Secured: true, Headers: ifMatch, Request: map[string]any{}, RequestType: mergepatch.ContentType, Response: models.Address{}},
+		{Method: "DELETE", Path: "/v1/addresses" + idPath, Summary: "Delete an address", Tags: []string{"addresses"}, Secured: true, Headers: ifMatch, Status: http.StatusNoContent},
+
+		{Method: "GET", Path: "/v1/admin/orders", Summary: "List all orders (admin)", Tags: []string{"admin"}, Secured: true, Query: queryParams([]openapi.Parameter{customerIDQuery}, orderFilterQuery, orderPageQuery), Response: []models.Order{}},
+		{Method: "GET", Path: "/v1/admin/orders/export", Summary: "Export orders as CSV or NDJSON (admin)", Tags: []string{"admin"}, Secured: true, Query: queryParams([]openapi.Parameter{customerIDQuery}, orderFilterQuery, []openapi.Parameter{exportFormatQuery}), Response: "", ContentType: "text/csv"},
+		{Method: "POST", Path: "/v1/admin/orders/status", Summary: "Update the status of several orders (admin)", Tags: []string{"admin"}, Secured: true, Request: services.OrderStatusesRequest{}, Response: controllers.OrderStatusesResponse{}},
+
+		{Method: "GET", Path: "/v1/products", Summary: "List products", Tags: []string{"products"}, Headers: ifNoneMatch, Response: []models.Product{}},
+		{Method: "GET", Path: "/v1/products" + idPath, Summary: "Get a product", Tags: []string{"products"}, Headers: ifNoneMatch, Secured: true, Response: models.Product{}},
+		{Method: "POST", Path: "/v1/products", Summary: "Create a product (admin)", Tags: []string{"products"}, Secured: true, Headers: idempotencyKey, Request: services.ProductRequest{}, Response: models.Product{}, Status: http.StatusCreated},
+		{Method: "PUT", Path: "/v1/products" + idPath, Summary: "Update a product (admin)", Tags: []string{"products"}, Secured: true, Headers: ifMatch, Request: services.ProductRequest{}, Response: models.Product{}},
+		{Method: "PATCH", Path: "/v1/products" + idPath, Summary: "Partially update a product (admin)", Tags: []string{"products"}, Secured: true, Headers: ifMatch, Request: map[string]any{}, RequestType: mergepatch.ContentType, Response: models.Product{}},
+		{Method: "DELETE", Path: "/v1/products" + idPath, Summary: "Delete a product (admin)", Tags: []string{"products"}, Secured: true, Headers: ifMatch, Status: http.StatusNoContent},
+
+		{Method: "GET", Path: "/v1/orders" + idPath, Summary: "Get an order", Tags: []string{"orders"}, Secured: true, Response: models.Order{}},
+		{Method: "POST", Path: "/v1/orders", Summary: "Create an order", Tags: []string{"orders"}, Secured: true, Headers: idempotencyKey, Request: services.OrderRequest{}, Response: models.Order{}, Status: http.StatusCreated},
+		{Method: "PUT", Path: "/v1/orders" + idPath, Summary: "Update an order", Tags: []string{"orders"}, Secured: true, Headers: ifMatch, Request: services.OrderRequest{}, Response: models.Order{}},
+	}
+}
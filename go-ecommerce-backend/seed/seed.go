@@ -0,0 +1,241 @@
+package seed
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+//go:embed fixtures.json
+var defaultFixtures []byte
+
+var ErrAlreadySeeded = errors.New("database already contains fixture users")
+
+type Fixtures struct {
+	Products []services.ProductRequest `json:"products"`
+	Users    []UserFixture             `json:"users"`
+}
+
+type UserFixture struct {
+	Email    string           `json:"email" validate:"required,email"`
+	Password string           `json:"password" validate:"required,min=6"`
+	Role     models.Role      `json:"role" validate:"required,oneof=customer admin"`
+	Customer *CustomerFixture `json:"customer"`
+}
+
+type CustomerFixture struct {
+	FirstName   string                    `json:"first_name" validate:"required"`
+	LastName    string                    `json:"last_name" validate:"required"`
+	PhoneNumber string                    `json:"phone_number" validate:"required,max=15"`
+	Addresses   []services.AddressRequest `json:"addresses"`
+	Orders      []OrderFixture            `json:"orders"`
+}
+
+type OrderFixture struct {
+	Status models.OrderStatus `json:"status" validate:"required,oneof=pending completed cancelled"`
+	Items  []OrderItemFixture `json:"items" validate:"required,dive"`
+}
+
+type OrderItemFixture struct {
+	Product  string `json:"product" validate:"required"`
+	Quantity int    `json:"quantity" validate:"required,gt=0"`
+}
+
+func DefaultFixtures() (*Fixtures, error) {
+	return parseFixtures(defaultFixtures)
+}
+
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseFixtures(data)
+}
+
+func parseFixtures(data []byte) (*Fixtures, error) {
+	var fixtures Fixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("invalid fixtures: %w", err)
+	}
+	for i := range fixtures.Products {
+		if err := utils.ValidateStruct(fixtures.Products[i]); err != nil {
+			return nil, fmt.Errorf("invalid fixture product %q: %w", fixtures.Products[i].Name, err)
+		}
+	}
+	for _, user := range fixtures.Users {
+		if err := utils.ValidateStruct(user); err != nil {
+			return nil, fmt.Errorf("invalid fixture user %q: %w", user.Email, err)
+		}
+		if user.Customer == nil {
+			continue
+		}
+		if err := utils.ValidateStruct(user.Customer); err != nil {
+			return nil, fmt.Errorf("invalid fixture customer for %q: %w", user.Email, err)
+		}
+		for _, order := range user.Customer.Orders {
+			if err := utils.ValidateStruct(order); err != nil {
+				return nil, fmt.Errorf("invalid fixture order for %q: %w", user.Email, err)
+			}
+		}
+	}
+	return &fixtures, nil
+}
+
+type Summary struct {
+	Products  int
+	Users     int
+	Customers int
+	Addresses int
+	Orders    int
+}
+
+type Seeder struct {
+	Tx repositories.TxManager
+}
+
+func NewSeeder(repos *repositories.Repositories) *Seeder {
+	return &Seeder{Tx: repos.Tx}
+}
+
+type loader struct {
+	UserRepo        repositories.UserRepository
+	ProductRepo     repositories.ProductRepository
+	AuthService     services.AuthService
+	CustomerService services.CustomerService
+	AddressService  services.AddressService
+	ProductService  services.ProductService
+	OrderService    services.OrderService
+}
+
+func newLoader(repos *repositories.Repositories) *loader {
+	return &loader{
+		UserRepo:        repos.Users,
+		ProductRepo:     repos.Products,
+		AuthService:     services.NewAuthService(repos.Users, repos.Tx, utils.JWTOptions{}),
+		CustomerService: services.NewCustomerService(repos.Customers),
+		AddressService:  services.NewAddressService(repos.Addresses),
+		ProductService:  services.NewProductService(repos.Products),
+		OrderService:    services.NewOrderService(repos.Orders, repos.Customers, repos.Addresses, repos.Tx),
+	}
+}
+
+func (s *Seeder) Load(ctx context.Context, fixtures *Fixtures) (*Summary, error) {
+	var summary *Summary
+	err := s.Tx.WithinTx(ctx, func(repos *repositories.Repositories) error {
+		summary = &Summary{}
+		return newLoader(repos).load(ctx, fixtures, summary)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func (l *loader) load(ctx context.Context, fixtures *Fixtures, summary *Summary) error {
+	for _, user := range fixtures.Users {
+		_, err := l.UserRepo.GetByEmail(ctx, user.Email)
+		if err == nil {
+			return fmt.Errorf("%w: %s", ErrAlreadySeeded, user.Email)
+		}
+		if !errors.Is(err, apperrors.ErrNotFound) {
+			return err
+		}
+	}
+
+	productIDs, err := l.upsertProducts(ctx, fixtures.Products, summary)
+	if err != nil {
+		return err
+	}
+
+	for _, fixture := range fixtures.Users {
+		user, err := l.AuthService.CreateUser(ctx, fixture.Email, fixture.Password, fixture.Role)
+		if err != nil {
+			return fmt.Errorf("failed to create user %q: %w", fixture.Email, err)
+		}
+		summary.Users++
+
+		if fixture.Customer != nil {
+			if err := l.loadCustomer(ctx, user, fixture.Customer, productIDs, summary); err != nil {
+				return fmt.Errorf("failed to create customer data for %q: %w", fixture.Email, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (l *loader) upsertProducts(ctx context.Context, fixtures []services.ProductRequest, summary *Summary) (map[string]int, error) {
+	existing, err := l.ProductRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	productIDs := map[string]int{}
+	for _, product := range existing {
+		productIDs[product.Name] = product.ID
+	}
+
+	for i := range fixtures {
+		req := &fixtures[i]
+		var product *models.Product
+		if id, ok := productIDs[req.Name]; ok {
+			product, err = l.ProductService.UpdateProduct(ctx, id, req)
+		} else {
+			product, err = l.ProductService.CreateProduct(ctx, req)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert product %q: %w", req.Name, err)
+		}
+		productIDs[product.Name] = product.ID
+		summary.Products++
+	}
+	return productIDs, nil
+}
+
+func (l *loader) loadCustomer(ctx context.Context, user *models.User, fixture *CustomerFixture, productIDs map[string]int, summary *Summary) error {
+	customer, err := l.CustomerService.CreateCustomer(ctx, &services.CustomerRequest{
+		UserID:      user.ID,
+		FirstName:   fixture.FirstName,
+		LastName:    fixture.LastName,
+		PhoneNumber: fixture.PhoneNumber,
+	})
+	if err != nil {
+		return err
+	}
+	summary.Customers++
+
+	for _, address := range fixture.Addresses {
+		address.CustomerID = customer.ID
+		if err := utils.ValidateStruct(address); err != nil {
+			return fmt.Errorf("invalid fixture address: %w", err)
+		}
+		if _, err := l.AddressService.CreateAddress(ctx, &address); err != nil {
+			return err
+		}
+		summary.Addresses++
+	}
+
+	for _, order := range fixture.Orders {
+		req := &services.OrderRequest{CustomerID: customer.ID, Status: order.Status}
+		for _, item := range order.Items {
+			productID, ok := productIDs[item.Product]
+			if !ok {
+				return fmt.Errorf("order references unknown product %q", item.Product)
+			}
+			req.OrderItems = append(req.OrderItems, services.OrderItemRequest{ProductID: productID, Quantity: item.Quantity})
+		}
+		if _, err := l.OrderService.CreateOrder(ctx, req); err != nil {
+			return err
+		}
+		summary.Orders++
+	}
+	return nil
+}
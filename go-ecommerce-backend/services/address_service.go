@@ -1,17 +1,19 @@
 package services
 
 import (
+	"context"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
 )
 
 type AddressService interface {
-	GetAddressByID(id int) (*models.Address, error)
-	GetAddressesByCustomerID(id int) ([]*models.Address, error)
-	CreateAddress(req *AddressRequest) (*models.Address, error)
-	UpdateAddress(id int, req *AddressRequest) (*models.Address, error)
-	DeleteAddress(id int) error
-	GetOwnerID(id int) (int, error)
+	GetAddressByID(ctx context.Context, id int) (*models.Address, error)
+	GetAddressesByCustomerID(ctx context.Context, id int) ([]*models.Address, error)
+	CreateAddress(ctx context.Context, req *AddressRequest) (*models.Address, error)
+	UpdateAddress(ctx context.Context, id int, req *AddressRequest) (*models.Address, error)
+	PatchAddress(ctx context.Context, id int, patch []byte) (*models.Address, error)
+	DeleteAddress(ctx context.Context, id int) error
+	GetOwnerID(ctx context.Context, id int) (int, error)
 }
 
 type addressService struct {
@@ -31,41 +33,105 @@ type AddressRequest struct {
 	Country       string `json:"country" validate:"required"`
 }
 
-func (as *addressService) GetAddressByID(id int) (*models.Address, error) {
-	return as.AddressRepo.GetByID(id)
+func (as *addressService) GetAddressByID(ctx context.Context, id int) (*models.Address, error) {
+	ctx, span := tracer.Start(ctx, "AddressService.GetAddressByID")
+	defer span.End()
+
+	return as.AddressRepo.GetByID(ctx, id)
 }
 
-func (as *addressService) GetAddressesByCustomerID(id int) ([]*models.Address, error) {
-	return as.AddressRepo.GetByCustomerID(id)
+func (as *addressService) GetAddressesByCustomerID(ctx context.Context, id int) ([]*models.Address, error) {
+	ctx, span := tracer.Start(ctx, "AddressService.GetAddressesByCustomerID")
+	defer span.End()
+
+	return as.AddressRepo.GetByCustomerID(ctx, id)
 }
 
-func (as *addressService) CreateAddress(req *AddressRequest) (*models.Address, error) {
+func (as *addressService) CreateAddress(ctx context.Context, req *AddressRequest) (*models.Address, error) {
+	ctx, span := tracer.Start(ctx, "AddressService.CreateAddress")
+	defer span.End()
+
 	address := &models.Address{
 		CustomerID:    req.CustomerID,
 		StreetAddress: req.StreetAddress,
 		City:          req.City,
 		Country:       req.Country,
 	}
-	err := as.AddressRepo.Create(address)
+	err := as.AddressRepo.Create(ctx, address)
 	return address, err
 }
 
-func (as *addressService) UpdateAddress(id int, req *AddressRequest) (*models.Address, error) {
-	address, err := as.AddressRepo.GetByID(id)
+func (as *addressService) UpdateAddress(ctx context.Context, id int, req *AddressRequest) (*models.Address, error) {
+	ctx, span := tracer.Start(ctx, "AddressService.UpdateAddress")
+	defer span.End()
+
+	address, err := as.AddressRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkVersion(ctx, address.Version, "address"); err != nil {
+		return nil, err
+	}
 	address.StreetAddress = req.StreetAddress
 	address.City = req.City
 	address.Country = req.Country
-	err = as.AddressRepo.Update(address)
+	err = as.AddressRepo.Update(ctx, address)
 	return address, err
 }
 
-func (as *addressService) DeleteAddress(id int) error {
-	return as.AddressRepo.Delete(id)
+func (as *addressService) PatchAddress(ctx context.Context, id int, patch []byte) (*models.Address, error) {
+	ctx, span := tracer.Start(ctx, "AddressService.PatchAddress")
+	defer span.End()
+
+	address, err := as.AddressRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, address.Version, "address"); err != nil {
+		return nil, err
+	}
+
+	req := AddressRequest{
+		CustomerID:    address.CustomerID,
+		StreetAddress: address.StreetAddress,
+		City:          address.City,
+		Country:       address.Country,
+	}
+	if err := applyMergePatch(&req, patch, "id", "customer_id", "version"); err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	columns = setIfChanged(&address.StreetAddress, req.StreetAddress, "street_address", columns)
+	columns = setIfChanged(&address.City, req.City, "city", columns)
+	columns = setIfChanged(&address.Country, req.Country, "country", columns)
+	if len(columns) == 0 {
+		return address, nil
+	}
+	err = as.AddressRepo.Patch(ctx, address, columns)
+	return address, err
 }
 
-func (as *addressService) GetOwnerID(id int) (int, error) {
-	return as.AddressRepo.GetOwnerID(id)
+func (as *addressService) DeleteAddress(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "AddressService.DeleteAddress")
+	defer span.End()
+
+	version, err := expectedVersion(ctx, "address", func() (int, error) {
+		address, err := as.AddressRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return address.Version, nil
+	})
+	if err != nil {
+		return err
+	}
+	return as.AddressRepo.Delete(ctx, id, version)
+}
+
+func (as *addressService) GetOwnerID(ctx context.Context, id int) (int, error) {
+	ctx, span := tracer.Start(ctx, "AddressService.GetOwnerID")
+	defer span.End()
+
+	return as.AddressRepo.GetOwnerID(ctx, id)
 }
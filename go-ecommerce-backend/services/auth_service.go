@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
 	"errors"
 
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/metrics"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
 
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
@@ -12,19 +15,22 @@ import (
 )
 
 type AuthService interface {
-	Login(req *LoginRequest) (*models.User, string, error)
-	Register(req *RegisterRequest) error
+	Login(ctx context.Context, req *LoginRequest) (*models.User, string, error)
+	Register(ctx context.Context, req *RegisterRequest) error
+	CreateUser(ctx context.Context, email, password string, role models.Role) (*models.User, error)
 }
 
 type authService struct {
-	UserRepo  repositories.UserRepository
-	JWTSecret string
+	UserRepo repositories.UserRepository
+	Tx       repositories.TxManager
+	JWT      utils.JWTOptions
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwtSecret string) AuthService {
+func NewAuthService(userRepo repositories.UserRepository, tx repositories.TxManager, jwt utils.JWTOptions) AuthService {
 	return &authService{
-		UserRepo:  userRepo,
-		JWTSecret: jwtSecret,
+		UserRepo: userRepo,
+		Tx:       tx,
+		JWT:      jwt,
 	}
 }
 
@@ -33,17 +39,25 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
-func (a *authService) Login(req *LoginRequest) (*models.User, string, error) {
-	user, err := a.UserRepo.GetByEmail(req.Email)
+func (a *authService) Login(ctx context.Context, req *LoginRequest) (*models.User, string, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer span.End()
+
+	user, err := a.UserRepo.GetByEmail(ctx, req.Email)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		metrics.LoginFailures.Inc()
+		return nil, "", apperrors.ErrInvalidCredentials
+	}
 	if err != nil {
-		return nil, "", errors.New("invalid credentials")
+		return nil, "", err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, "", errors.New("invalid credentials")
+		metrics.LoginFailures.Inc()
+		return nil, "", apperrors.ErrInvalidCredentials
 	}
 
-	token, err := utils.GenerateJWT(user.ID, string(user.Role), a.JWTSecret)
+	token, err := utils.GenerateJWT(user.ID, string(user.Role), a.JWT)
 	if err != nil {
 		return nil, "", err
 	}
@@ -52,21 +66,67 @@ func (a *authService) Login(req *LoginRequest) (*models.User, string, error) {
 }
 
 type RegisterRequest struct {
-	Email    string      `json:"email" validate:"required,email"`
-	Password string      `json:"password" validate:"required,min=6"`
-	Role     models.Role `json:"role" validate:"required"`
+	Email    string                   `json:"email" validate:"required,email"`
+	Password string                   `json:"password" validate:"required,min=6"`
+	Role     models.Role              `json:"role" validate:"required,oneof=customer"`
+	Customer *RegisterCustomerRequest `json:"customer,omitempty"`
+}
+
+type RegisterCustomerRequest struct {
+	FirstName   string `json:"first_name" validate:"required"`
+	LastName    string `json:"last_name" validate:"required"`
+	PhoneNumber string `json:"phone_number" validate:"required,max=15"`
 }
 
-func (a *authService) Register(req *RegisterRequest) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+func (a *authService) Register(ctx context.Context, req *RegisterRequest) error {
+	ctx, span := tracer.Start(ctx, "AuthService.Register")
+	defer span.End()
+
+	if req.Customer == nil {
+		_, err := a.CreateUser(ctx, req.Email, req.Password, req.Role)
+		return err
+	}
+
+	user, err := newUser(req.Email, req.Password, req.Role)
 	if err != nil {
 		return err
 	}
+	return a.Tx.WithinTx(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Users.Create(ctx, user); err != nil {
+			return err
+		}
+		customer := &models.Customer{
+			UserID:      user.ID,
+			FirstName:   req.Customer.FirstName,
+			LastName:    req.Customer.LastName,
+			PhoneNumber: req.Customer.PhoneNumber,
+		}
+		return repos.Customers.Create(ctx, customer)
+	})
+}
 
-	user := &models.User{
-		Email:    req.Email,
-		Password: string(hashedPassword),
-		Role:     req.Role,
+func (a *authService) CreateUser(ctx context.Context, email, password string, role models.Role) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.CreateUser")
+	defer span.End()
+
+	user, err := newUser(email, password, role)
+	if err != nil {
+		return nil, err
 	}
-	return a.UserRepo.Create(user)
+	if err := a.UserRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func newUser(email, password string, role models.Role) (*models.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return &models.User{
+		Email:    email,
+		Password: string(hashedPassword),
+		Role:     role,
+	}, nil
 }
@@ -1,17 +1,19 @@
 package services
 
 import (
+	"context"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
 )
 
 type CustomerService interface {
-	GetCustomerByID(id int) (*models.Customer, error)
-	GetCustomerByUserID(id int) (*models.Customer, error)
-	CreateCustomer(req *CustomerRequest) (*models.Customer, error)
-	UpdateCustomer(id int, req *CustomerRequest) (*models.Customer, error)
-	DeleteCustomer(id int) error
-	GetOwnerID(id int) (int, error)
+	GetCustomerByID(ctx context.Context, id int) (*models.Customer, error)
+	GetCustomerByUserID(ctx context.Context, id int) (*models.Customer, error)
+	CreateCustomer(ctx context.Context, req *CustomerRequest) (*models.Customer, error)
+	UpdateCustomer(ctx context.Context, id int, req *CustomerRequest) (*models.Customer, error)
+	PatchCustomer(ctx context.Context, id int, patch []byte) (*models.Customer, error)
+	DeleteCustomer(ctx context.Context, id int) error
+	GetOwnerID(ctx context.Context, id int) (int, error)
 }
 
 type customerService struct {
@@ -31,45 +33,109 @@ type CustomerRequest struct {
 	PhoneNumber string `json:"phone_number" validate:"required,max=15"`
 }
 
-func (cs *customerService) GetCustomerByID(id int) (*models.Customer, error) {
-	return cs.CustomerRepo.GetByID(id)
+func (cs *customerService) GetCustomerByID(ctx context.Context, id int) (*models.Customer, error) {
+	ctx, span := tracer.Start(ctx, "CustomerService.GetCustomerByID")
+	defer span.End()
+
+	return cs.CustomerRepo.GetByID(ctx, id)
 }
 
-func (cs *customerService) GetCustomerByUserID(id int) (*models.Customer, error) {
-	return cs.CustomerRepo.GetByUserID(id)
+func (cs *customerService) GetCustomerByUserID(ctx context.Context, id int) (*models.Customer, error) {
+	ctx, span := tracer.Start(ctx, "CustomerService.GetCustomerByUserID")
+	defer span.End()
+
+	return cs.CustomerRepo.GetByUserID(ctx, id)
 }
 
-func (cs *customerService) CreateCustomer(req *CustomerRequest) (*models.Customer, error) {
+func (cs *customerService) CreateCustomer(ctx context.Context, req *CustomerRequest) (*models.Customer, error) {
+	ctx, span := tracer.Start(ctx, "CustomerService.CreateCustomer")
+	defer span.End()
+
 	customer := &models.Customer{
 		UserID:      req.UserID,
 		FirstName:   req.FirstName,
 		LastName:    req.LastName,
 		PhoneNumber: req.PhoneNumber,
 	}
-	err := cs.CustomerRepo.Create(customer)
+	err := cs.CustomerRepo.Create(ctx, customer)
 	return customer, err
 }
 
-func (cs *customerService) UpdateCustomer(id int, req *CustomerRequest) (*models.Customer, error) {
-	customer, err := cs.CustomerRepo.GetByID(id)
+func (cs *customerService) UpdateCustomer(ctx context.Context, id int, req *CustomerRequest) (*models.Customer, error) {
+	ctx, span := tracer.Start(ctx, "CustomerService.UpdateCustomer")
+	defer span.End()
+
+	customer, err := cs.CustomerRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkVersion(ctx, customer.Version, "customer"); err != nil {
+		return nil, err
+	}
 	customer.FirstName = req.FirstName
 	customer.LastName = req.LastName
 	customer.PhoneNumber = req.PhoneNumber
-	err = cs.CustomerRepo.Update(customer)
+	err = cs.CustomerRepo.Update(ctx, customer)
 	return customer, err
 }
 
-func (cs *customerService) DeleteCustomer(id int) error {
-	return cs.CustomerRepo.Delete(id)
+func (cs *customerService) PatchCustomer(ctx context.Context, id int, patch []byte) (*models.Customer, error) {
+	ctx, span := tracer.Start(ctx, "CustomerService.PatchCustomer")
+	defer span.End()
+
+	customer, err := cs.CustomerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, customer.Version, "customer"); err != nil {
+		return nil, err
+	}
+
+	req := CustomerRequest{
+		UserID:      customer.UserID,
+		FirstName:   customer.FirstName,
+		LastName:    customer.LastName,
+		PhoneNumber: customer.PhoneNumber,
+	}
+	if err := applyMergePatch(&req, patch, "id", "user_id", "version"); err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	columns = setIfChanged(&customer.FirstName, req.FirstName, "first_name", columns)
+	columns = setIfChanged(&customer.LastName, req.LastName, "last_name", columns)
+	columns = setIfChanged(&customer.PhoneNumber, req.PhoneNumber, "phone_number", columns)
+	if len(columns) == 0 {
+		return customer, nil
+	}
+	err = cs.CustomerRepo.Patch(ctx, customer, columns)
+	return customer, err
 }
 
-func (cs *customerService) GetOwnerID(id int) (int, error) {
-	customer, err := cs.GetCustomerByID(id)
+func (cs *customerService) DeleteCustomer(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "CustomerService.DeleteCustomer")
+	defer span.End()
+
+	version, err := expectedVersion(ctx, "customer", func() (int, error) {
+		customer, err := cs.CustomerRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return customer.Version, nil
+	})
+	if err != nil {
+		return err
+	}
+	return cs.CustomerRepo.Delete(ctx, id, version)
+}
+
+func (cs *customerService) GetOwnerID(ctx context.Context, id int) (int, error) {
+	ctx, span := tracer.Start(ctx, "CustomerService.GetOwnerID")
+	defer span.End()
+
+	customer, err := cs.GetCustomerByID(ctx, id)
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
-	return customer.UserID, err
+	return customer.UserID, nil
 }
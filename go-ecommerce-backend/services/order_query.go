@@ -0,0 +1,105 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+)
+
+const (
+	DefaultOrderPageSize = 20
+	MaxOrderPageSize     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type OrderListQuery struct {
+	CustomerID    int
+	ProductID     int
+	Statuses      []models.OrderStatus
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	MinTotal      *float64
+	MaxTotal      *float64
+	Sort          repositories.OrderSort
+	Ascending     bool
+	Limit         int
+	Cursor        *repositories.OrderCursor
+	Summary       bool
+}
+
+type OrderPage struct {
+	Orders     []*models.Order
+	NextCursor string
+}
+
+func (q OrderListQuery) filter() repositories.OrderFilter {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultOrderPageSize
+	}
+	sort := q.Sort
+	if sort == "" {
+		sort = repositories.OrderSortCreatedAt
+	}
+	return repositories.OrderFilter{
+		CustomerID:    q.CustomerID,
+		ProductID:     q.ProductID,
+		Statuses:      q.Statuses,
+		CreatedFrom:   q.CreatedAfter,
+		CreatedBefore: q.CreatedBefore,
+		MinTotal:      q.MinTotal,
+		MaxTotal:      q.MaxTotal,
+		Sort:          sort,
+		Ascending:     q.Ascending,
+		After:         q.Cursor,
+		Limit:         min(limit, MaxOrderPageSize),
+		WithItems:     !q.Summary,
+	}
+}
+
+func EncodeOrderCursor(cursor repositories.OrderCursor) string {
+	raw := strings.Join([]string{
+		cursor.CreatedAt.UTC().Format(time.RFC3339Nano),
+		strconv.FormatFloat(cursor.Total, 'f', -1, 64),
+		strconv.Itoa(cursor.ID),
+		string(cursor.Sort),
+		strconv.FormatBool(cursor.Ascending),
+	}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeOrderCursor(value string) (*repositories.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 5 {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &repositories.OrderCursor{}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, parts[0]); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Total, err = strconv.ParseFloat(parts[1], 64); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.ID, err = strconv.Atoi(parts[2]); err != nil || cursor.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	switch cursor.Sort = repositories.OrderSort(parts[3]); cursor.Sort {
+	case repositories.OrderSortCreatedAt, repositories.OrderSortTotal:
+	default:
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Ascending, err = strconv.ParseBool(parts[4]); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
@@ -1,32 +1,51 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/metrics"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/preconditions"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
 )
 
 type OrderService interface {
-	GetOrderByID(id int) (*models.Order, error)
-	GetOrdersByCustomerID(id int) ([]*models.Order, error)
-	CreateOrder(req *OrderRequest) (*models.Order, error)
-	UpdateOrder(id int, req *OrderRequest) (*models.Order, error)
-	GetOwnerID(id int) (int, error)
+	GetOrderByID(ctx context.Context, id int) (*models.Order, error)
+	GetOrdersByCustomerID(ctx context.Context, id int, query OrderListQuery) (*OrderPage, error)
+	ListOrders(ctx context.Context, query OrderListQuery) (*OrderPage, error)
+	ExportOrders(ctx context.Context, query OrderListQuery, fn func(order *models.Order) error) error
+	CreateOrder(ctx context.Context, req *OrderRequest) (*models.Order, error)
+	UpdateOrder(ctx context.Context, id int, req *OrderRequest) (*models.Order, error)
+	UpdateOrderStatuses(ctx context.Context, req *OrderStatusesRequest) []OrderStatusResult
+	GetOwnerID(ctx context.Context, id int) (int, error)
 }
 
 type orderService struct {
-	OrderRepo repositories.OrderRepository
+	OrderRepo    repositories.OrderRepository
+	CustomerRepo repositories.CustomerRepository
+	AddressRepo  repositories.AddressRepository
+	Tx           repositories.TxManager
 }
 
-func NewOrderService(repo repositories.OrderRepository) OrderService {
+func NewOrderService(repo repositories.OrderRepository, customerRepo repositories.CustomerRepository, addressRepo repositories.AddressRepository, tx repositories.TxManager) OrderService {
 	return &orderService{
-		OrderRepo: repo,
+		OrderRepo:    repo,
+		CustomerRepo: customerRepo,
+		AddressRepo:  addressRepo,
+		Tx:           tx,
 	}
 }
 
 type OrderRequest struct {
-	CustomerID int                `json:"customer_id" validate:"required"`
-	Status     models.OrderStatus `json:"status" validate:"required,oneof=pending completed cancelled"`
-	OrderItems []OrderItemRequest `json:"order_items" validate:"required"`
+	CustomerID        int                `json:"customer_id" validate:"required"`
+	Status            models.OrderStatus `json:"status" validate:"required,oneof=pending completed cancelled"`
+	OrderItems        []OrderItemRequest `json:"order_items" validate:"required"`
+	ShippingAddressID int                `json:"shipping_address_id" validate:"omitempty,gt=0"`
+	BillingAddressID  int                `json:"billing_address_id" validate:"omitempty,gt=0"`
 }
 
 type OrderItemRequest struct {
@@ -34,15 +53,74 @@ type OrderItemRequest struct {
 	Quantity  int `json:"quantity" validate:"required,gt=0"`
 }
 
-func (os *orderService) GetOrderByID(id int) (*models.Order, error) {
-	return os.OrderRepo.GetByID(id)
+type OrderStatusesRequest struct {
+	OrderIDs []int              `json:"order_ids" validate:"required,min=1,max=100,unique,dive,gt=0"`
+	Status   models.OrderStatus `json:"status" validate:"required,oneof=pending completed cancelled"`
+	Versions map[int]int        `json:"versions,omitempty" validate:"dive,gt=0"`
+}
+
+type OrderStatusResult struct {
+	ID    int
+	Order *models.Order
+	Err   error
+}
+
+func (os *orderService) GetOrderByID(ctx context.Context, id int) (*models.Order, error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrderByID")
+	defer span.End()
+
+	return os.OrderRepo.GetByID(ctx, id)
 }
 
-func (os *orderService) GetOrdersByCustomerID(id int) ([]*models.Order, error) {
-	return os.OrderRepo.GetOrdersByCustomerID(id)
+func (os *orderService) GetOrdersByCustomerID(ctx context.Context, id int, query OrderListQuery) (*OrderPage, error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrdersByCustomerID")
+	defer span.End()
+
+	filter := query.filter()
+	filter.CustomerID = id
+	return os.listOrders(ctx, filter)
+}
+
+func (os *orderService) ListOrders(ctx context.Context, query OrderListQuery) (*OrderPage, error) {
+	ctx, span := tracer.Start(ctx, "OrderService.ListOrders")
+	defer span.End()
+
+	return os.listOrders(ctx, query.filter())
 }
 
-func (os *orderService) CreateOrder(req *OrderRequest) (*models.Order, error) {
+func (os *orderService) ExportOrders(ctx context.Context, query OrderListQuery, fn func(order *models.Order) error) error {
+	ctx, span := tracer.Start(ctx, "OrderService.ExportOrders")
+	defer span.End()
+
+	filter := query.filter()
+	filter.Limit, filter.After, filter.WithItems = 0, nil, false
+	return os.OrderRepo.Stream(ctx, filter, fn)
+}
+
+func (os *orderService) listOrders(ctx context.Context, filter repositories.OrderFilter) (*OrderPage, error) {
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	orders, err := os.OrderRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &OrderPage{Orders: orders}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		last := page.Orders[limit-1]
+		page.NextCursor = EncodeOrderCursor(repositories.OrderCursor{CreatedAt: last.CreatedAt, Total: last.Total, ID: last.ID, Sort: filter.Sort, Ascending: filter.Ascending})
+	}
+	if page.Orders == nil {
+		page.Orders = []*models.Order{}
+	}
+	return page, nil
+}
+
+func (os *orderService) CreateOrder(ctx context.Context, req *OrderRequest) (*models.Order, error) {
+	ctx, span := tracer.Start(ctx, "OrderService.CreateOrder")
+	defer span.End()
+
 	var orderItems []models.OrderItem
 	for _, item := range req.OrderItems {
 		orderItem := models.OrderItem{
@@ -56,20 +134,149 @@ func (os *orderService) CreateOrder(req *OrderRequest) (*models.Order, error) {
 		Status:     req.Status,
 		OrderItems: orderItems,
 	}
-	err := os.OrderRepo.Create(order)
-	return order, err
+	if err := os.attachAddresses(ctx, order, req); err != nil {
+		return nil, err
+	}
+	if err := os.OrderRepo.Create(ctx, order); err != nil {
+		if errors.Is(err, apperrors.ErrInsufficientStock) {
+			metrics.InsufficientStockRejections.Inc()
+			slog.InfoContext(ctx, "order rejected", "customer_id", order.CustomerID, "reason", err)
+		}
+		return nil, err
+	}
+	metrics.OrdersCreated.Inc()
+	slog.InfoContext(ctx, "order created", "order_id", order.ID, "customer_id", order.CustomerID, "items", len(order.OrderItems))
+	return order, nil
+}
+
+func (os *orderService) attachAddresses(ctx context.Context, order *models.Order, req *OrderRequest) error {
+	ownerID, err := os.customerOwnerID(ctx, req.CustomerID)
+	if err != nil {
+		return err
+	}
+	if order.ShippingAddress, err = os.snapshotAddress(ctx, ownerID, req.ShippingAddressID, "shipping_address_id"); err != nil {
+		return err
+	}
+	order.BillingAddress, err = os.snapshotAddress(ctx, ownerID, req.BillingAddressID, "billing_address_id")
+	return err
+}
+
+func (os *orderService) customerOwnerID(ctx context.Context, customerID int) (int, error) {
+	customer, err := os.CustomerRepo.GetByID(ctx, customerID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return 0, apperrors.InvalidInput("customer_id must reference an existing customer")
+	}
+	if err != nil {
+		return 0, err
+	}
+	if callerID, ok := ctx.Value(middlewares.ContextUserID).(int); ok && callerID != customer.UserID {
+		return 0, apperrors.Forbidden("customer_id belongs to another user")
+	}
+	return customer.UserID, nil
 }
 
-func (os *orderService) UpdateOrder(id int, req *OrderRequest) (*models.Order, error) {
-	order, err := os.OrderRepo.GetByID(id)
+func (os *orderService) snapshotAddress(ctx context.Context, ownerID, id int, field string) (*models.OrderAddress, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	invalid := apperrors.InvalidInput(field + " must reference an address of the ordering customer")
+
+	addressOwnerID, err := os.AddressRepo.GetOwnerID(ctx, id)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, invalid
+	}
 	if err != nil {
 		return nil, err
 	}
-	order.Status = req.Status
-	err = os.OrderRepo.Update(order)
+	if addressOwnerID != ownerID {
+		return nil, invalid
+	}
+
+	address, err := os.AddressRepo.GetByID(ctx, id)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, invalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return address.Snapshot(), nil
+}
+
+func (os *orderService) UpdateOrder(ctx context.Context, id int, req *OrderRequest) (*models.Order, error) {
+	ctx, span := tracer.Start(ctx, "OrderService.UpdateOrder")
+	defer span.End()
+
+	return os.updateStatus(ctx, id, req.Status)
+}
+
+func (os *orderService) UpdateOrderStatuses(ctx context.Context, req *OrderStatusesRequest) []OrderStatusResult {
+	ctx, span := tracer.Start(ctx, "OrderService.UpdateOrderStatuses")
+	defer span.End()
+
+	var failed int
+	results := make([]OrderStatusResult, len(req.OrderIDs))
+	for i, id := range req.OrderIDs {
+		orderCtx := ctx
+		if version, ok := req.Versions[id]; ok {
+			orderCtx = preconditions.ContextWithExpectedVersions(ctx, []int{version})
+		}
+		order, err := os.updateStatus(orderCtx, id, req.Status)
+		if err != nil {
+			failed++
+		}
+		results[i] = OrderStatusResult{ID: id, Order: order, Err: err}
+	}
+	slog.InfoContext(ctx, "order statuses updated", "status", req.Status, "updated", len(results)-failed, "failed", failed)
+	return results
+}
+
+func (os *orderService) updateStatus(ctx context.Context, id int, status models.OrderStatus) (*models.Order, error) {
+	order, err := os.OrderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, order.Version, "order"); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case order.Status == models.OrderStatusCancelled && status != models.OrderStatusCancelled:
+		return nil, apperrors.Conflict("a cancelled order cannot be reopened")
+	case order.Status != models.OrderStatusCancelled && status == models.OrderStatusCancelled:
+		if err := os.cancelOrder(ctx, order); err != nil {
+			return nil, err
+		}
+		return order, nil
+	}
+	order.Status = status
+	err = os.OrderRepo.Update(ctx, order)
 	return order, err
 }
 
-func (os *orderService) GetOwnerID(id int) (int, error) {
-	return os.OrderRepo.GetOwnerID(id)
+func (os *orderService) cancelOrder(ctx context.Context, order *models.Order) error {
+	version := order.Version
+	err := os.Tx.WithinTx(ctx, func(repos *repositories.Repositories) error {
+		order.Status, order.Version = models.OrderStatusCancelled, version
+		if err := repos.Orders.Update(ctx, order); err != nil {
+			return err
+		}
+		for _, item := range order.OrderItems {
+			if err := repos.Products.Restock(ctx, item.ProductID, item.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "order cancelled", "order_id", order.ID, "customer_id", order.CustomerID, "items", len(order.OrderItems))
+	return nil
+}
+
+func (os *orderService) GetOwnerID(ctx context.Context, id int) (int, error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetOwnerID")
+	defer span.End()
+
+	return os.OrderRepo.GetOwnerID(ctx, id)
 }
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"encoding/json"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/mergepatch"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+func applyMergePatch[T any](req *T, patch []byte, immutable ...string) error {
+	if err := checkImmutable(patch, immutable); err != nil {
+		return err
+	}
+	if err := mergepatch.ApplyTo(req, patch); err != nil {
+		return apperrors.DecodeError(err)
+	}
+	return utils.ValidateStruct(req)
+}
+
+func checkImmutable(patch []byte, immutable []string) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil {
+		return nil
+	}
+	for _, field := range immutable {
+		if _, ok := members[field]; ok {
+			return &apperrors.ImmutableFieldError{Field: field}
+		}
+	}
+	return nil
+}
+
+func setIfChanged[T comparable](field *T, value T, column string, columns []string) []string {
+	if *field == value {
+		return columns
+	}
+	*field = value
+	return append(columns, column)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/preconditions"
+)
+
+func expectedVersion(ctx context.Context, resource string, current func() (int, error)) (int, error) {
+	versions, ok := preconditions.ExpectedVersions(ctx)
+	switch {
+	case !ok:
+		return 0, nil
+	case len(versions) == 1:
+		return versions[0], nil
+	}
+
+	version, err := current()
+	if err != nil {
+		return 0, err
+	}
+	if err := checkVersion(ctx, version, resource); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func checkVersion(ctx context.Context, current int, resource string) error {
+	if expected, ok := preconditions.ExpectedVersions(ctx); ok && !slices.Contains(expected, current) {
+		return apperrors.PreconditionFailed(resource)
+	}
+	return nil
+}
@@ -1,16 +1,18 @@
 package services
 
 import (
+	"context"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
 )
 
 type ProductService interface {
-	GetProductByID(id int) (*models.Product, error)
-	CreateProduct(req *ProductRequest) (*models.Product, error)
-	UpdateProduct(id int, req *ProductRequest) (*models.Product, error)
-	DeleteProduct(id int) error
-	GetAllProducts() ([]*models.Product, error)
+	GetProductByID(ctx context.Context, id int) (*models.Product, error)
+	CreateProduct(ctx context.Context, req *ProductRequest) (*models.Product, error)
+	UpdateProduct(ctx context.Context, id int, req *ProductRequest) (*models.Product, error)
+	PatchProduct(ctx context.Context, id int, patch []byte) (*models.Product, error)
+	DeleteProduct(ctx context.Context, id int) error
+	GetAllProducts(ctx context.Context) ([]*models.Product, error)
 }
 
 type productService struct {
@@ -31,11 +33,17 @@ type ProductRequest struct {
 	Stock       int     `json:"stock" validate:"required,gt=-1"`
 }
 
-func (ps *productService) GetProductByID(id int) (*models.Product, error) {
-	return ps.ProductRepo.GetByID(id)
+func (ps *productService) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProductByID")
+	defer span.End()
+
+	return ps.ProductRepo.GetByID(ctx, id)
 }
 
-func (ps *productService) CreateProduct(req *ProductRequest) (*models.Product, error) {
+func (ps *productService) CreateProduct(ctx context.Context, req *ProductRequest) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.CreateProduct")
+	defer span.End()
+
 	product := &models.Product{
 		Name:        req.Name,
 		Description: req.Description,
@@ -43,28 +51,86 @@ func (ps *productService) CreateProduct(req *ProductRequest) (*models.Product, e
 		Price:       req.Price,
 		Stock:       req.Stock,
 	}
-	err := ps.ProductRepo.Create(product)
+	err := ps.ProductRepo.Create(ctx, product)
 	return product, err
 }
 
-func (ps *productService) UpdateProduct(id int, req *ProductRequest) (*models.Product, error) {
-	product, err := ps.ProductRepo.GetByID(id)
+func (ps *productService) UpdateProduct(ctx context.Context, id int, req *ProductRequest) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateProduct")
+	defer span.End()
+
+	product, err := ps.ProductRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkVersion(ctx, product.Version, "product"); err != nil {
+		return nil, err
+	}
 	product.Name = req.Name
 	product.Description = req.Description
 	product.Category = req.Category
 	product.Price = req.Price
 	product.Stock = req.Stock
-	err = ps.ProductRepo.Update(product)
+	err = ps.ProductRepo.Update(ctx, product)
+	return product, err
+}
+
+func (ps *productService) PatchProduct(ctx context.Context, id int, patch []byte) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.PatchProduct")
+	defer span.End()
+
+	product, err := ps.ProductRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, product.Version, "product"); err != nil {
+		return nil, err
+	}
+
+	req := ProductRequest{
+		Name:        product.Name,
+		Description: product.Description,
+		Category:    product.Category,
+		Price:       product.Price,
+		Stock:       product.Stock,
+	}
+	if err := applyMergePatch(&req, patch, "id", "version"); err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	columns = setIfChanged(&product.Name, req.Name, "name", columns)
+	columns = setIfChanged(&product.Description, req.Description, "description", columns)
+	columns = setIfChanged(&product.Category, req.Category, "category", columns)
+	columns = setIfChanged(&product.Price, req.Price, "price", columns)
+	columns = setIfChanged(&product.Stock, req.Stock, "stock", columns)
+	if len(columns) == 0 {
+		return product, nil
+	}
+	err = ps.ProductRepo.Patch(ctx, product, columns)
 	return product, err
 }
 
-func (ps *productService) DeleteProduct(id int) error {
-	return ps.ProductRepo.Delete(id)
+func (ps *productService) DeleteProduct(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "ProductService.DeleteProduct")
+	defer span.End()
+
+	version, err := expectedVersion(ctx, "product", func() (int, error) {
+		product, err := ps.ProductRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return product.Version, nil
+	})
+	if err != nil {
+		return err
+	}
+	return ps.ProductRepo.Delete(ctx, id, version)
 }
 
-func (ps *productService) GetAllProducts() ([]*models.Product, error) {
-	return ps.ProductRepo.GetAll()
+func (ps *productService) GetAllProducts(ctx context.Context) ([]*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetAllProducts")
+	defer span.End()
+
+	return ps.ProductRepo.GetAll(ctx)
 }
@@ -1,19 +1,19 @@
 package services
 
 import (
-	"errors"
-
+	"context"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
-	GetUserByID(id int) (*models.User, error)
-	UpdateUser(id int, userReq *UserRequest) (*models.User, error)
-	UpdateUserPassword(id int, req *UserRequest) (*models.User, error)
-	DeleteUser(id int) error
-	GetOwnerID(id int) (int, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	UpdateUser(ctx context.Context, id int, userReq *UserRequest) (*models.User, error)
+	UpdateUserPassword(ctx context.Context, id int, req *UserRequest) (*models.User, error)
+	DeleteUser(ctx context.Context, id int) error
+	GetOwnerID(ctx context.Context, id int) (int, error)
 }
 
 type userService struct {
@@ -32,34 +32,49 @@ type UserRequest struct {
 	Role     models.Role `json:"role" validate:"required,oneof=admin customer"`
 }
 
-func (us *userService) GetUserByID(id int) (*models.User, error) {
-	return us.UserRepo.GetByID(id)
+func (us *userService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
+	return us.UserRepo.GetByID(ctx, id)
 }
 
-func (us *userService) UpdateUser(id int, req *UserRequest) (*models.User, error) {
-	user, err := us.UserRepo.GetByID(id)
+func (us *userService) UpdateUser(ctx context.Context, id int, req *UserRequest) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	user, err := us.UserRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkVersion(ctx, user.Version, "user"); err != nil {
+		return nil, err
+	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, apperrors.Forbidden("password does not match")
 	}
 
 	user.Email = req.Email
 	user.Role = req.Role
-	err = us.UserRepo.Update(user)
+	err = us.UserRepo.Update(ctx, user)
 	return user, err
 }
 
-func (us *userService) UpdateUserPassword(id int, req *UserRequest) (*models.User, error) {
-	user, err := us.UserRepo.GetByID(id)
+func (us *userService) UpdateUserPassword(ctx context.Context, id int, req *UserRequest) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.UpdateUserPassword")
+	defer span.End()
+
+	user, err := us.UserRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkVersion(ctx, user.Version, "user"); err != nil {
+		return nil, err
+	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err == nil {
-		return nil, errors.New("password identical to saved one")
+		return nil, apperrors.Conflict("password identical to saved one")
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -67,16 +82,32 @@ func (us *userService) UpdateUserPassword(id int, req *UserRequest) (*models.Use
 		return nil, err
 	}
 	user.Password = string(hashedPassword)
-	err = us.UserRepo.Update(user)
+	err = us.UserRepo.Update(ctx, user)
 	return user, err
 }
 
-func (us *userService) DeleteUser(id int) error {
-	return us.UserRepo.Delete(id)
+func (us *userService) DeleteUser(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	version, err := expectedVersion(ctx, "user", func() (int, error) {
+		user, err := us.UserRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return user.Version, nil
+	})
+	if err != nil {
+		return err
+	}
+	return us.UserRepo.Delete(ctx, id, version)
 }
 
-func (us *userService) GetOwnerID(id int) (int, error) {
-	user, err := us.GetUserByID(id)
+func (us *userService) GetOwnerID(ctx context.Context, id int) (int, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetOwnerID")
+	defer span.End()
+
+	user, err := us.GetUserByID(ctx, id)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,317 @@
+package unit_tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/routes"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+type adminOrdersFixture struct {
+	router        *mux.Router
+	repos         *repositories.Repositories
+	adminToken    string
+	customerToken string
+	lamp, desk    *models.Product
+	orders        []*models.Order
+}
+
+func newAdminOrdersFixture(t *testing.T) *adminOrdersFixture {
+	ctx := context.Background()
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "test-secret"
+	f := &adminOrdersFixture{
+		router: routes.SetupRoutes(repos, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil),
+		repos:  repos,
+		lamp:   &models.Product{Name: "Lamp", Price: 10, Stock: 20},
+		desk:   &models.Product{Name: "Desk", Price: 150, Stock: 20},
+	}
+
+	admin := &models.User{Email: "admin@example.com", Password: "hash", Role: models.RoleAdmin}
+	user := &models.User{Email: "ada@example.com", Password: "hash", Role: models.RoleCustomer}
+	for _, u := range []*models.User{admin, user} {
+		if err := repos.Users.Create(ctx, u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	customer := &models.Customer{UserID: user.ID, FirstName: "Ada", LastName: "Lovelace"}
+	if err := repos.Customers.Create(ctx, customer); err != nil {
+		t.Fatalf("create customer: %v", err)
+	}
+	for _, p := range []*models.Product{f.lamp, f.desk} {
+		if err := repos.Products.Create(ctx, p); err != nil {
+			t.Fatalf("create product: %v", err)
+		}
+	}
+	for _, items := range [][]models.OrderItem{
+		{{ProductID: f.lamp.ID, Quantity: 1}},
+		{{ProductID: f.lamp.ID, Quantity: 2}, {ProductID: f.desk.ID, Quantity: 1}},
+		{{ProductID: f.lamp.ID, Quantity: 5}},
+	} {
+		order := &models.Order{CustomerID: customer.ID, Status: models.OrderStatusPending, OrderItems: items}
+		if len(f.orders) == 0 {
+			order.ShippingAddress = &models.OrderAddress{AddressID: 4, StreetAddress: "12 St James's Square", City: "London", Country: "UK"}
+		}
+		if err := repos.Orders.Create(ctx, order); err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+		f.orders = append(f.orders, order)
+	}
+
+	var err error
+	if f.adminToken, err = utils.GenerateJWT(admin.ID, string(models.RoleAdmin), cfg.Auth.JWT()); err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	if f.customerToken, err = utils.GenerateJWT(user.ID, string(models.RoleCustomer), cfg.Auth.JWT()); err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return f
+}
+
+func (f *adminOrdersFixture) do(method, target, token string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rr := httptest.NewRecorder()
+	f.router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAdminOrders_RequireAdminRole(t *testing.T) {
+	f := newAdminOrdersFixture(t)
+
+	for _, target := range []string{"/v1/admin/orders", "/v1/admin/orders/export"} {
+		rr := f.do("GET", target, f.customerToken, nil)
+		assert.Equal(t, http.StatusForbidden, rr.Code, target)
+	}
+	rr := f.do("POST", "/v1/admin/orders/status", f.customerToken, []byte(`{"order_ids":[1],"status":"completed"}`))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestAdminOrders_ListFiltersAndSorts(t *testing.T) {
+	f := newAdminOrdersFixture(t)
+
+	list := func(query string) []models.Order {
+		t.Helper()
+		rr := f.do("GET", "/v1/admin/orders?"+query, f.adminToken, nil)
+		if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+			return nil
+		}
+		var orders []models.Order
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&orders))
+		return orders
+	}
+	ids := func(orders []models.Order) []int {
+		var ids []int
+		for _, order := range orders {
+			ids = append(ids, order.ID)
+		}
+		return ids
+	}
+
+	byTotal := list("sort=-total")
+	assert.Equal(t, []int{f.orders[1].ID, f.orders[2].ID, f.orders[0].ID}, ids(byTotal))
+	if assert.Len(t, byTotal, 3) {
+		assert.Equal(t, 170.0, byTotal[0].Total)
+		assert.Len(t, byTotal[0].OrderItems, 2)
+	}
+
+	assert.Equal(t, []int{f.orders[1].ID}, ids(list("product_id="+strconv.Itoa(f.desk.ID))))
+	assert.Equal(t, []int{f.orders[2].ID, f.orders[0].ID}, ids(list("max_total=100&sort=-total")))
+	assert.Equal(t, []int{f.orders[2].ID}, ids(list("min_total=20&max_total=100")))
+	assert.Empty(t, list("status=completed"))
+
+	rr := f.do("GET", "/v1/admin/orders?sort=total&limit=2", f.adminToken, nil)
+	link := rr.Header().Get("Link")
+	if assert.NotEmpty(t, link) {
+		next := strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		rr = f.do("GET", next, f.adminToken, nil)
+		var rest []models.Order
+		if assert.NoError(t, json.NewDecoder(rr.Body).Decode(&rest)) {
+			assert.Equal(t, []int{f.orders[1].ID}, ids(rest), "The cursor should continue the total ordering")
+		}
+	}
+}
+
+func TestAdminOrders_ExportCSV(t *testing.T) {
+	f := newAdminOrdersFixture(t)
+
+	rr := f.do("GET", "/v1/admin/orders/export?sort=created_at", f.adminToken, nil)
+
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		return
+	}
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), `filename="orders.csv"`)
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if assert.NoError(t, err) && assert.Len(t, records, 4) {
+		assert.Equal(t, []string{
+			"id", "customer_id", "status", "total", "created_at", "version",
+			"shipping_address_id", "shipping_street_address", "shipping_city", "shipping_country",
+			"billing_address_id", "billing_street_address", "billing_city", "billing_country",
+		}, records[0])
+		assert.Equal(t, strconv.Itoa(f.orders[0].ID), records[1][0])
+		assert.Equal(t, []string{"4", "12 St James's Square", "London", "UK", "", "", "", ""}, records[1][6:])
+		assert.Equal(t, "170.00", records[2][3])
+		assert.Equal(t, []string{"", "", "", "", "", "", "", ""}, records[2][6:])
+	}
+}
+
+func TestAdminOrders_ExportCSVNeutralisesFormulas(t *testing.T) {
+	f := newAdminOrdersFixture(t)
+	order := &models.Order{
+		CustomerID:     f.orders[0].CustomerID,
+		Status:         models.OrderStatusPending,
+		OrderItems:     []models.OrderItem{{ProductID: f.lamp.ID, Quantity: 1}},
+		BillingAddress: &models.OrderAddress{AddressID: 5, StreetAddress: "=HYPERLINK(\"http://evil.example\")", City: "+London", Country: "\tUK"},
+	}
+	if err := f.repos.Orders.Create(context.Background(), order); err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	rr := f.do("GET", "/v1/admin/orders/export?sort=created_at", f.adminToken, nil)
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if assert.NoError(t, err) && assert.Len(t, records, 5) {
+		assert.Equal(t, []string{"5", "'=HYPERLINK(\"http://evil.example\")", "'+London", "'\tUK"}, records[4][10:])
+		assert.Equal(t, "12 St James's Square", records[1][7], "Plain text should be left alone")
+	}
+}
+
+func TestAdminOrders_ExportNDJSON(t *testing.T) {
+	f := newAdminOrdersFixture(t)
+
+	rr := f.do("GET", "/v1/admin/orders/export?format=ndjson&product_id="+strconv.Itoa(f.lamp.ID)+"&min_total=20", f.adminToken, nil)
+
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		return
+	}
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		var first map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+		assert.NotContains(t, first, "order_items")
+		assert.Contains(t, first, "shipping_address")
+	}
+
+	rr = f.do("GET", "/v1/admin/orders/export?format=xml", f.adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"invalid_query"`)
+}
+
+func TestAdminOrders_ExportRejectsPagingParameters(t *testing.T) {
+	f := newAdminOrdersFixture(t)
+
+	for _, query := range []string{"limit=1", "cursor=abc", "view=summary"} {
+		rr := f.do("GET", "/v1/admin/orders/export?"+query, f.adminToken, nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		assert.Contains(t, rr.Body.String(), `"code":"invalid_query"`, query)
+	}
+}
+
+func TestAdminOrders_BulkStatusFollowsOrderRules(t *testing.T) {
+	f := newAdminOrdersFixture(t)
+	ctx := context.Background()
+
+	rr := f.do("POST", "/v1/admin/orders/status", f.adminToken, []byte(`{"order_ids":[`+strconv.Itoa(f.orders[0].ID)+`],"status":"cancelled"}`))
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		return
+	}
+
+	body := `{"order_ids":[` + strconv.Itoa(f.orders[0].ID) + `,` + strconv.Itoa(f.orders[1].ID) + `,999],"status":"completed"}`
+	rr = f.do("POST", "/v1/admin/orders/status", f.adminToken, []byte(body))
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		return
+	}
+	var resp struct {
+		Updated int `json:"updated"`
+		Failed  int `json:"failed"`
+		Results []struct {
+			ID    int           `json:"id"`
+			Order *models.Order `json:"order"`
+			Error *struct {
+				Status int    `json:"status"`
+				Code   string `json:"code"`
+			} `json:"error"`
+		} `json:"results"`
+	}
+	if !assert.NoError(t, json.NewDecoder(rr.Body).Decode(&resp)) || !assert.Len(t, resp.Results, 3) {
+		return
+	}
+	assert.Equal(t, 1, resp.Updated)
+	assert.Equal(t, 2, resp.Failed)
+	if assert.NotNil(t, resp.Results[0].Error, "A cancelled order cannot be reopened") {
+		assert.Equal(t, "conflict", resp.Results[0].Error.Code)
+	}
+	if assert.NotNil(t, resp.Results[1].Order) {
+		assert.Equal(t, models.OrderStatusCompleted, resp.Results[1].Order.Status)
+	}
+	if assert.NotNil(t, resp.Results[2].Error) {
+		assert.Equal(t, http.StatusNotFound, resp.Results[2].Error.Status)
+	}
+
+	lamp, err := f.repos.Products.GetByID(ctx, f.lamp.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 20-2-5, lamp.Stock, "Cancelling through the bulk endpoint should restock")
+	}
+
+	rr = f.do("POST", "/v1/admin/orders/status", f.adminToken, []byte(`{"order_ids":[],"status":"completed"}`))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAdminOrders_BulkStatusChecksVersionsPerOrder(t *testing.T) {
+	f := newAdminOrdersFixture(t)
+	first, second := strconv.Itoa(f.orders[0].ID), strconv.Itoa(f.orders[1].ID)
+
+	body := `{"order_ids":[` + first + `,` + second + `],"status":"completed","versions":{"` + first + `":` + strconv.Itoa(f.orders[0].Version) + `,"` + second + `":` + strconv.Itoa(f.orders[1].Version+1) + `}}`
+	rr := f.do("POST", "/v1/admin/orders/status", f.adminToken, []byte(body))
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		return
+	}
+	var resp struct {
+		Updated int `json:"updated"`
+		Results []struct {
+			Error *struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		} `json:"results"`
+	}
+	if assert.NoError(t, json.NewDecoder(rr.Body).Decode(&resp)) && assert.Len(t, resp.Results, 2) {
+		assert.Equal(t, 1, resp.Updated)
+		assert.Nil(t, resp.Results[0].Error)
+		if assert.NotNil(t, resp.Results[1].Error, "A stale version should only fail its own order") {
+			assert.Equal(t, "precondition_failed", resp.Results[1].Error.Code)
+		}
+	}
+
+	rr = f.do("POST", "/v1/admin/orders/status", f.adminToken, []byte(`{"order_ids":[`+first+`],"status":"completed","versions":{"999":1}}`))
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Versions must refer to listed orders")
+
+	req := httptest.NewRequest("POST", "/v1/admin/orders/status", strings.NewReader(`{"order_ids":[`+first+`],"status":"completed"}`))
+	req.Header.Set("Authorization", "Bearer "+f.adminToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	rr = httptest.NewRecorder()
+	f.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "A single If-Match cannot apply to several orders")
+}
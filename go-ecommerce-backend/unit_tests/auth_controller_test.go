@@ -1,6 +1,7 @@
 package unit_tests
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -9,24 +10,31 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/controllers"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
 )
 
 type MockAuthService struct {
-	LoginFunc    func(req *services.LoginRequest) (*models.User, string, error)
-	RegisterFunc func(req *services.RegisterRequest) error
+	LoginFunc      func(req *services.LoginRequest) (*models.User, string, error)
+	RegisterFunc   func(req *services.RegisterRequest) error
+	CreateUserFunc func(email, password string, role models.Role) (*models.User, error)
 }
 
-func (m *MockAuthService) Login(req *services.LoginRequest) (*models.User, string, error) {
+func (m *MockAuthService) Login(ctx context.Context, req *services.LoginRequest) (*models.User, string, error) {
 	return m.LoginFunc(req)
 }
 
-func (m *MockAuthService) Register(req *services.RegisterRequest) error {
+func (m *MockAuthService) Register(ctx context.Context, req *services.RegisterRequest) error {
 	return m.RegisterFunc(req)
 }
 
+func (m *MockAuthService) CreateUser(ctx context.Context, email, password string, role models.Role) (*models.User, error) {
+	return m.CreateUserFunc(email, password, role)
+}
+
 func TestAuthController_Login_Success(t *testing.T) {
 	mockUser := &models.User{
 		ID:    1,
@@ -50,7 +58,7 @@ func TestAuthController_Login_Success(t *testing.T) {
 
 	rr := httptest.NewRecorder()
 
-	authController.Login(rr, nil, loginReq)
+	authController.Login(rr, httptest.NewRequest("POST", "/v1/login", nil), loginReq)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Expected status code 200 on successful login")
 	var respBody map[string]any
@@ -68,7 +76,7 @@ func TestAuthController_Login_Success(t *testing.T) {
 func TestAuthController_Login_Failure(t *testing.T) {
 	mockService := &MockAuthService{
 		LoginFunc: func(req *services.LoginRequest) (*models.User, string, error) {
-			return nil, "", errors.New("invalid credentials")
+			return nil, "", apperrors.ErrInvalidCredentials
 		},
 	}
 
@@ -80,7 +88,7 @@ func TestAuthController_Login_Failure(t *testing.T) {
 	}
 
 	rr := httptest.NewRecorder()
-	authController.Login(rr, nil, loginReq)
+	authController.Login(rr, httptest.NewRequest("POST", "/v1/login", nil), loginReq)
 
 	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Expected status code 401 on failed login")
 }
@@ -101,7 +109,7 @@ func TestAuthController_Register_Success(t *testing.T) {
 	}
 
 	rr := httptest.NewRecorder()
-	authController.Register(rr, nil, registerReq)
+	authController.Register(rr, httptest.NewRequest("POST", "/v1/register", nil), registerReq)
 
 	assert.Equal(t, http.StatusCreated, rr.Code, "Expected status code 201 on successful registration")
 
@@ -127,7 +135,33 @@ func TestAuthController_Register_Failure(t *testing.T) {
 	}
 
 	rr := httptest.NewRecorder()
-	authController.Register(rr, nil, registerReq)
+	authController.Register(rr, httptest.NewRequest("POST", "/v1/register", nil), registerReq)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Expected status code 500 on registration failure")
 }
+
+func TestAuthController_Register_Conflict(t *testing.T) {
+	mockService := &MockAuthService{
+		RegisterFunc: func(req *services.RegisterRequest) error {
+			return apperrors.Conflict("user already exists")
+		},
+	}
+
+	authController := controllers.NewAuthController(mockService)
+
+	registerReq := &services.RegisterRequest{
+		Email:    "taken@example.com",
+		Password: "password",
+		Role:     models.RoleCustomer,
+	}
+
+	rr := httptest.NewRecorder()
+	authController.Register(rr, httptest.NewRequest("POST", "/v1/register", nil), registerReq)
+
+	assert.Equal(t, http.StatusConflict, rr.Code, "Expected status code 409 when the email is already registered")
+
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err, "Expected valid JSON response")
+	assert.Equal(t, problems.CodeConflict, problem.Code, "Expected conflict code")
+}
@@ -0,0 +1,99 @@
+package unit_tests
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/cli"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/seed"
+)
+
+func TestCLI_InvalidUsage(t *testing.T) {
+	t.Setenv("ADMIN_PASSWORD", "secret123")
+	t.Setenv("ADMIN_PASSWORD_FILE", "")
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no command", nil},
+		{"unknown command", []string{"launch"}},
+		{"migrate without subcommand", []string{"migrate"}},
+		{"migrate down without steps", []string{"migrate", "down"}},
+		{"migrate down with invalid steps", []string{"migrate", "down", "two"}},
+		{"migrate goto zero", []string{"migrate", "goto", "0"}},
+		{"migrate status with argument", []string{"migrate", "status", "1"}},
+		{"serve with unknown flag", []string{"serve", "--port", "80"}},
+		{"user without subcommand", []string{"user"}},
+		{"create-admin without email", []string{"user", "create-admin"}},
+		{"create-admin with password flag", []string{"user", "create-admin", "--email", "admin@example.com", "--password", "secret123"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := cli.Run(context.Background(), tt.args, &stdout, &stderr)
+
+			assert.ErrorIs(t, err, cli.ErrUsage)
+			assert.Contains(t, stderr.String(), "Usage:", "Usage should be printed on invalid input")
+		})
+	}
+}
+
+func TestCLI_CreateAdminPasswordFromEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		file     string
+	}{
+		{"short password", "123", ""},
+		{"missing password", "", ""},
+		{"password and file", "secret123", writeFile(t, "admin_password", "secret123\n")},
+		{"unreadable file", "", filepath.Join(t.TempDir(), "missing")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_PASSWORD", tt.password)
+			t.Setenv("ADMIN_PASSWORD_FILE", tt.file)
+			var stdout, stderr bytes.Buffer
+			err := cli.Run(context.Background(), []string{"user", "create-admin", "--email", "admin@example.com"}, &stdout, &stderr)
+
+			assert.ErrorIs(t, err, cli.ErrUsage)
+		})
+	}
+}
+
+func TestCLI_Help(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := cli.Run(context.Background(), []string{"help"}, &stdout, &stderr)
+
+	assert.NoError(t, err)
+	for _, command := range []string{"serve", "migrate up", "migrate down", "migrate goto", "migrate force", "migrate status", "seed", "user create-admin"} {
+		assert.Contains(t, stdout.String(), command)
+	}
+}
+
+func TestSeed_DefaultFixturesAreValid(t *testing.T) {
+	fixtures, err := seed.DefaultFixtures()
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, fixtures.Products)
+	assert.NotEmpty(t, fixtures.Users)
+
+	products := map[string]bool{}
+	for _, product := range fixtures.Products {
+		products[product.Name] = true
+	}
+	for _, user := range fixtures.Users {
+		if user.Customer == nil {
+			continue
+		}
+		for _, order := range user.Customer.Orders {
+			for _, item := range order.Items {
+				assert.True(t, products[item.Product], "Order item should reference a fixture product: %s", item.Product)
+			}
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package unit_tests
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+func setConfigEnv(t *testing.T, env map[string]string) {
+	for _, name := range []string{"CONFIG_FILE", "PORT", "DB_PROD", "DB_PROD_FILE", "DB_TEST", "JWT_SECRET", "JWT_SECRET_FILE", "JWT_TOKEN_TTL", "LOG_LEVEL", "DB_READ_TIMEOUT", "DB_EXPORT_TIMEOUT", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "RATE_LIMIT_POLICIES", "RATE_LIMIT_TRUSTED_PROXIES", "IDEMPOTENCY_TTL", "IDEMPOTENCY_LEASE", "IDEMPOTENCY_PURGE_INTERVAL", "STORAGE"} {
+		t.Setenv(name, "")
+	}
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+}
+
+func writeFile(t *testing.T, name, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfig_DefaultsWithRequiredSettings(t *testing.T) {
+	setConfigEnv(t, map[string]string{"DB_PROD": "postgres://prod", "JWT_SECRET": "secret"})
+
+	cfg, err := config.Load(config.Sources{})
+
+	if !assert.NoError(t, err, "DB_TEST should not be required") {
+		return
+	}
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Equal(t, 72*time.Hour, cfg.Auth.TokenTTL)
+	assert.Equal(t, 25, cfg.Database.MaxOpenConns)
+	assert.Equal(t, "default", cfg.RateLimit.Policies["default"].Name)
+}
+
+func TestConfig_Precedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+server:
+  port: "9000"
+  read_timeout: 20s
+database:
+  url: postgres://from-file
+  read_timeout: 3s
+auth:
+  jwt_secret: file-secret
+  issuer: shop
+logging:
+  level: debug
+`)
+	setConfigEnv(t, map[string]string{"PORT": "9100", "DB_READ_TIMEOUT": "4s", "DB_EXPORT_TIMEOUT": "10m"})
+
+	cfg, err := config.Load(config.Sources{File: file, Overrides: []string{"server.port=9200", "features.require_if_match=true"}})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "9200", cfg.Server.Port, "Flags should override env and file")
+	assert.Equal(t, 4*time.Second, cfg.Database.ReadTimeout, "Env should override the file")
+	assert.Equal(t, 10*time.Minute, cfg.Database.ExportTimeout)
+	assert.Equal(t, 20*time.Second, cfg.Server.ReadTimeout, "File should override defaults")
+	assert.Equal(t, "postgres://from-file", cfg.Database.URL)
+	assert.Equal(t, "shop", cfg.Auth.Issuer)
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.True(t, cfg.Features.RequireIfMatch)
+}
+
+func TestConfig_FileFromEnv(t *testing.T) {
+	file := writeFile(t, "config.yaml", "database:\n  url: postgres://from-file\nauth:\n  jwt_secret: secret\n")
+	setConfigEnv(t, map[string]string{"CONFIG_FILE": file})
+
+	cfg, err := config.Load(config.Sources{})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "postgres://from-file", cfg.Database.URL)
+}
+
+func TestConfig_RateLimitPoliciesFromFile(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+database:
+  url: postgres://prod
+auth:
+  jwt_secret: secret
+rate_limit:
+  trusted_proxies: 10.0.0.0/8, 192.168.1.1
+  policies:
+    default: 10/1s burst=5 key=user
+    POST /v1/login: 3/1m
+`)
+	setConfigEnv(t, nil)
+
+	cfg, err := config.Load(config.Sources{File: file})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, cfg.RateLimit.Policies, 2, "Policies in the file should replace the defaults")
+	login := cfg.RateLimit.Policies["POST /v1/login"]
+	assert.Equal(t, "POST /v1/login", login.Name)
+	assert.Equal(t, 3, login.Limit)
+	assert.Equal(t, 5, cfg.RateLimit.Policies["default"].Burst)
+	assert.True(t, cfg.RateLimit.TrustedProxies.Contains(netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, cfg.RateLimit.TrustedProxies.Contains(netip.MustParseAddr("192.168.1.1")))
+	assert.False(t, cfg.RateLimit.TrustedProxies.Contains(netip.MustParseAddr("192.168.1.2")))
+}
+
+func TestConfig_SecretsFromFiles(t *testing.T) {
+	setConfigEnv(t, map[string]string{
+		"DB_PROD_FILE":    writeFile(t, "db_url", "postgres://secret\n"),
+		"JWT_SECRET_FILE": writeFile(t, "jwt_secret", "from-file\n"),
+	})
+
+	cfg, err := config.Load(config.Sources{})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "postgres://secret", cfg.Database.URL)
+	assert.Equal(t, "from-file", cfg.Auth.JWTSecret, "Trailing newlines should be trimmed")
+}
+
+func TestConfig_SecretAndSecretFileConflict(t *testing.T) {
+	setConfigEnv(t, map[string]string{
+		"DB_PROD":         "postgres://prod",
+		"JWT_SECRET":      "secret",
+		"JWT_SECRET_FILE": writeFile(t, "jwt_secret", "other"),
+	})
+
+	_, err := config.Load(config.Sources{})
+
+	if !assert.Error(t, err) {
+		return
+	}
+	assert.Contains(t, err.Error(), "JWT_SECRET and JWT_SECRET_FILE are both set")
+}
+
+func TestConfig_ReportsAllErrors(t *testing.T) {
+	setConfigEnv(t, map[string]string{
+		"JWT_TOKEN_TTL":     "forever",
+		"LOG_LEVEL":         "loud",
+		"DB_MAX_OPEN_CONNS": "5",
+		"DB_MAX_IDLE_CONNS": "10",
+	})
+
+	_, err := config.Load(config.Sources{Overrides: []string{"server.port=abc", "server.nope=1", "missing-equals"}})
+
+	if !assert.Error(t, err) {
+		return
+	}
+	for _, message := range []string{
+		"JWT_TOKEN_TTL: must be a duration",
+		"--set server.nope: unknown setting",
+		"--set missing-equals: expected path=value",
+		"database.url is required",
+		"auth.jwt_secret is required",
+		"logging.level must be one of debug, info, warn, error",
+		"server.port must be numeric",
+		"database.max_idle_conns must not exceed database.max_open_conns",
+	} {
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+func TestConfig_MemoryStorageNeedsNoDatabase(t *testing.T) {
+	setConfigEnv(t, map[string]string{"STORAGE": "memory", "JWT_SECRET": "secret"})
+
+	cfg, err := config.Load(config.Sources{})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, config.StorageMemory, cfg.Storage)
+
+	setConfigEnv(t, map[string]string{"STORAGE": "sqlite", "JWT_SECRET": "secret"})
+	_, err = config.Load(config.Sources{})
+	assert.ErrorContains(t, err, "storage must be one of postgres, memory")
+}
+
+func TestConfig_UnknownFileKey(t *testing.T) {
+	file := writeFile(t, "config.yaml", "server:\n  prot: \"9000\"\n")
+	setConfigEnv(t, nil)
+
+	_, err := config.Load(config.Sources{File: file})
+
+	if !assert.Error(t, err) {
+		return
+	}
+	assert.Contains(t, err.Error(), "prot")
+}
+
+func TestJWT_IssuerAndTTL(t *testing.T) {
+	opts := utils.JWTOptions{Secret: "secret", TTL: time.Hour, Issuer: "shop"}
+	token, err := utils.GenerateJWT(7, "admin", opts)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	claims, err := utils.ValidateJWT(token, opts)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 7, claims.UserID)
+	assert.Equal(t, "shop", claims.Issuer)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), time.Unix(claims.ExpiresAt, 0), time.Minute)
+
+	_, err = utils.ValidateJWT(token, utils.JWTOptions{Secret: "secret", Issuer: "other"})
+	assert.Error(t, err, "A token from another issuer should be rejected")
+
+	expired, err := utils.GenerateJWT(7, "admin", utils.JWTOptions{Secret: "secret", TTL: -time.Minute, Issuer: "shop"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = utils.ValidateJWT(expired, opts)
+	assert.Error(t, err, "An expired token should be rejected")
+}
+
+func TestConfig_ExampleFileIsValid(t *testing.T) {
+	setConfigEnv(t, map[string]string{"JWT_SECRET": "secret"})
+
+	cfg, err := config.Load(config.Sources{File: "../config.example.yaml"})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, config.Default().RateLimit.Policies, cfg.RateLimit.Policies, "The example should match the default policies")
+}
@@ -0,0 +1,198 @@
+package unit_tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+)
+
+type MockIdempotencyRepository struct {
+	mu          sync.Mutex
+	records     map[string]*models.IdempotencyRecord
+	completeErr error
+}
+
+func NewMockIdempotencyRepository() *MockIdempotencyRepository {
+	return &MockIdempotencyRepository{records: map[string]*models.IdempotencyRecord{}}
+}
+
+func (m *MockIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord, ttl, lease time.Duration) (*models.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.records[record.Key]; ok {
+		copied := *existing
+		return &copied, nil
+	}
+	reserved := *record
+	m.records[record.Key] = &reserved
+	return nil, nil
+}
+
+func (m *MockIdempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.completeErr != nil {
+		return m.completeErr
+	}
+	completed := *record
+	m.records[record.Key] = &completed
+	return nil
+}
+
+func (m *MockIdempotencyRepository) Release(ctx context.Context, record *models.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, record.Key)
+	return nil
+}
+
+func (m *MockIdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func newIdempotentRequest(key, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/v1/orders", strings.NewReader(body))
+	req.Header.Set(middlewares.IdempotencyKeyHeader, key)
+	return req.WithContext(context.WithValue(req.Context(), middlewares.ContextUserID, 1))
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	handler := middlewares.IdempotencyMiddleware(NewMockIdempotencyRepository(), time.Hour, time.Minute)(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int{"id": calls})
+	})
+
+	first := httptest.NewRecorder()
+	handler(first, newIdempotentRequest("key-1", `{"customer_id": 1}`))
+	second := httptest.NewRecorder()
+	handler(second, newIdempotentRequest("key-1", `{"customer_id": 1}`))
+
+	assert.Equal(t, 1, calls, "Handler should run once per idempotency key")
+	assert.Equal(t, http.StatusCreated, second.Code, "Replay should return the original status")
+	assert.JSONEq(t, first.Body.String(), second.Body.String(), "Replay should return the original body")
+	assert.Equal(t, `"1"`, second.Header().Get("ETag"), "Replay should restore stored headers")
+	assert.Equal(t, "true", second.Header().Get(middlewares.IdempotentReplayedHeader))
+}
+
+func TestIdempotency_DifferentBody(t *testing.T) {
+	handler := middlewares.IdempotencyMiddleware(NewMockIdempotencyRepository(), time.Hour, time.Minute)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	handler(httptest.NewRecorder(), newIdempotentRequest("key-1", `{"customer_id": 1}`))
+	rr := httptest.NewRecorder()
+	handler(rr, newIdempotentRequest("key-1", `{"customer_id": 2}`))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Expected status code 422 when a key is reused with another body")
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err)
+	assert.Equal(t, problems.CodeIdempotencyKeyReused, problem.Code)
+}
+
+func TestIdempotency_ConcurrentDuplicate(t *testing.T) {
+	repo := NewMockIdempotencyRepository()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := middlewares.IdempotencyMiddleware(repo, time.Hour, time.Minute)(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		handler(httptest.NewRecorder(), newIdempotentRequest("key-1", `{}`))
+		close(done)
+	}()
+	<-started
+
+	rr := httptest.NewRecorder()
+	handler(rr, newIdempotentRequest("key-1", `{}`))
+	close(release)
+	<-done
+
+	assert.Equal(t, http.StatusConflict, rr.Code, "Expected status code 409 while the first request is in flight")
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	calls := 0
+	handler := middlewares.IdempotencyMiddleware(NewMockIdempotencyRepository(), time.Hour, time.Minute)(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	handler(httptest.NewRecorder(), newIdempotentRequest("key-1", `{}`))
+	rr := httptest.NewRecorder()
+	handler(rr, newIdempotentRequest("key-1", `{}`))
+
+	assert.Equal(t, 2, calls, "A failed request should not be replayed")
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
+
+func TestIdempotency_FailedCompleteKeepsKey(t *testing.T) {
+	repo := NewMockIdempotencyRepository()
+	repo.completeErr = errors.New("connection reset")
+	calls := 0
+	handler := middlewares.IdempotencyMiddleware(repo, time.Hour, time.Minute)(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	handler(httptest.NewRecorder(), newIdempotentRequest("key-1", `{}`))
+	rr := httptest.NewRecorder()
+	handler(rr, newIdempotentRequest("key-1", `{}`))
+
+	assert.Equal(t, 1, calls, "The side effect already happened, so the key must not be released")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	calls := 0
+	handler := middlewares.IdempotencyMiddleware(NewMockIdempotencyRepository(), time.Hour, time.Minute)(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/orders", strings.NewReader(`{}`)))
+	handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/orders", strings.NewReader(`{}`)))
+
+	assert.Equal(t, 2, calls, "Requests without a key should always run")
+}
+
+func TestIdempotency_IgnoresAnonymousRequests(t *testing.T) {
+	calls := 0
+	handler := middlewares.IdempotencyMiddleware(NewMockIdempotencyRepository(), time.Hour, time.Minute)(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/v1/orders", strings.NewReader(`{}`))
+		req.Header.Set(middlewares.IdempotencyKeyHeader, "key-1")
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		assert.Empty(t, rr.Header().Get(middlewares.IdempotentReplayedHeader))
+	}
+
+	assert.Equal(t, 2, calls, "Anonymous clients must not share one key space")
+}
@@ -0,0 +1,211 @@
+package unit_tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/controllers"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/mergepatch"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+)
+
+type MockProductRepository struct {
+	Product        models.Product
+	PatchedColumns []string
+}
+
+func (m *MockProductRepository) Create(ctx context.Context, product *models.Product) error {
+	return nil
+}
+
+func (m *MockProductRepository) GetByID(ctx context.Context, id int) (*models.Product, error) {
+	product := m.Product
+	return &product, nil
+}
+
+func (m *MockProductRepository) Update(ctx context.Context, product *models.Product) error {
+	return nil
+}
+
+func (m *MockProductRepository) Patch(ctx context.Context, product *models.Product, columns []string) error {
+	m.PatchedColumns = columns
+	product.Version++
+	return nil
+}
+
+func (m *MockProductRepository) Delete(ctx context.Context, id int, version int) error {
+	return nil
+}
+
+func (m *MockProductRepository) Restock(ctx context.Context, id int, quantity int) error {
+	return nil
+}
+
+func (m *MockProductRepository) GetAll(ctx context.Context) ([]*models.Product, error) {
+	return nil, nil
+}
+
+func newPatchRequest(body string) *http.Request {
+	req := httptest.NewRequest("PATCH", "/v1/products/1", strings.NewReader(body))
+	req.Header.Set("Content-Type", mergepatch.ContentType)
+	return mux.SetURLVars(req, map[string]string{"id": "1"})
+}
+
+func TestMergePatch_Apply(t *testing.T) {
+	merged, err := mergepatch.Apply(
+		[]byte(`{"a":"b","c":{"d":"e","f":"g"}}`),
+		[]byte(`{"a":"z","c":{"f":null}}`),
+	)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":"z","c":{"d":"e"}}`, string(merged), "Patch should replace values and remove null members")
+}
+
+func TestMergePatchBody_UnsupportedMediaType(t *testing.T) {
+	handler := middlewares.MergePatchBody(func(w http.ResponseWriter, r *http.Request, patch []byte) {
+		t.Fatal("handler should not be called for a plain JSON body")
+	})
+
+	req := httptest.NewRequest("PATCH", "/v1/products/1", strings.NewReader(`{"stock": 5}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code, "Expected status code 415 for a non merge-patch body")
+	assert.Equal(t, mergepatch.ContentType, rr.Header().Get("Accept-Patch"), "Expected Accept-Patch to advertise merge patch")
+}
+
+func TestMergePatchBody_NotAnObject(t *testing.T) {
+	handler := middlewares.MergePatchBody(func(w http.ResponseWriter, r *http.Request, patch []byte) {
+		t.Fatal("handler should not be called for a non-object patch")
+	})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, newPatchRequest(`[1, 2]`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Expected status code 400 for a non-object patch")
+}
+
+func TestProductController_PatchProduct_WritesChangedColumns(t *testing.T) {
+	repo := &MockProductRepository{Product: models.Product{ID: 1, Name: "Mug", Description: "Blue", Category: "kitchen", Price: 9.5, Stock: 3, Version: 2}}
+	productController := controllers.NewProductController(services.NewProductService(repo))
+	rr := httptest.NewRecorder()
+
+	middlewares.MergePatchBody(productController.PatchProduct).ServeHTTP(rr, newPatchRequest(`{"stock": 10, "name": "Mug"}`))
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Expected status code 200 for a valid patch")
+	assert.Equal(t, []string{"stock"}, repo.PatchedColumns, "Only changed columns should be written")
+	assert.Equal(t, `"3"`, rr.Header().Get("ETag"), "ETag should carry the new version")
+
+	var product models.Product
+	err := json.NewDecoder(rr.Body).Decode(&product)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, product.Stock, "Stock should be patched")
+	assert.Equal(t, "Blue", product.Description, "Untouched fields should be kept")
+}
+
+func TestProductController_PatchProduct_ValidatesResult(t *testing.T) {
+	repo := &MockProductRepository{Product: models.Product{ID: 1, Name: "Mug", Description: "Blue", Category: "kitchen", Price: 9.5, Stock: 3}}
+	productController := controllers.NewProductController(services.NewProductService(repo))
+	rr := httptest.NewRecorder()
+
+	middlewares.MergePatchBody(productController.PatchProduct).ServeHTTP(rr, newPatchRequest(`{"name": null, "price": -1}`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Expected status code 400 when the patched product is invalid")
+	assert.Nil(t, repo.PatchedColumns, "Nothing should be written for an invalid patch")
+
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err)
+	assert.Equal(t, problems.CodeValidationFailed, problem.Code, "Expected validation_failed code")
+	assert.ElementsMatch(t, []problems.FieldError{
+		{Field: "name", Rule: "required"},
+		{Field: "price", Rule: "gt", Param: "0"},
+	}, problem.Errors)
+}
+
+func TestProductController_PatchProduct_WrongType(t *testing.T) {
+	repo := &MockProductRepository{Product: models.Product{ID: 1, Name: "Mug", Description: "Blue", Category: "kitchen", Price: 9.5, Stock: 3}}
+	productController := controllers.NewProductController(services.NewProductService(repo))
+	rr := httptest.NewRecorder()
+
+	middlewares.MergePatchBody(productController.PatchProduct).ServeHTTP(rr, newPatchRequest(`{"stock": "ten"}`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Expected status code 400 for a patch with the wrong type")
+}
+
+func TestProductController_PatchProduct_UnknownField(t *testing.T) {
+	repo := &MockProductRepository{Product: models.Product{ID: 1, Name: "Mug", Description: "Blue", Category: "kitchen", Price: 9.5, Stock: 3}}
+	productController := controllers.NewProductController(services.NewProductService(repo))
+	rr := httptest.NewRecorder()
+
+	middlewares.MergePatchBody(productController.PatchProduct).ServeHTTP(rr, newPatchRequest(`{"colour": "red"}`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Expected status code 400 for a patch with an unknown field")
+	assert.Nil(t, repo.PatchedColumns)
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err)
+	assert.Equal(t, problems.CodeUnknownField, problem.Code, "Merge patches should report unknown fields like JSON bodies do")
+	assert.Equal(t, `Request body contains unknown field "colour"`, problem.Detail)
+	assert.Equal(t, []problems.FieldError{{Field: "colour", Rule: "unknown"}}, problem.Errors)
+}
+
+func TestPatch_RejectsImmutableFields(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	ctx := context.Background()
+	user := &models.User{Email: "ada@example.com", Password: "hash", Role: models.RoleCustomer}
+	assert.NoError(t, repos.Users.Create(ctx, user))
+	customer := &models.Customer{UserID: user.ID, FirstName: "Ada", LastName: "Lovelace", PhoneNumber: "555"}
+	assert.NoError(t, repos.Customers.Create(ctx, customer))
+	address := &models.Address{CustomerID: customer.ID, StreetAddress: "1 Main St", City: "London", Country: "UK"}
+	assert.NoError(t, repos.Addresses.Create(ctx, address))
+
+	customerController := controllers.NewCustomerController(services.NewCustomerService(repos.Customers))
+	addressController := controllers.NewAddressController(services.NewAddressService(repos.Addresses))
+	productController := controllers.NewProductController(services.NewProductService(&MockProductRepository{Product: models.Product{ID: 1, Name: "Mug", Description: "Blue", Category: "kitchen", Price: 9.5, Stock: 3}}))
+
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter, r *http.Request, patch []byte)
+		body    string
+		field   string
+	}{
+		{"customer owner", customerController.PatchCustomer, `{"user_id": 99, "first_name": "Grace"}`, "user_id"},
+		{"customer id", customerController.PatchCustomer, `{"id": 99}`, "id"},
+		{"address owner", addressController.PatchAddress, `{"customer_id": 99}`, "customer_id"},
+		{"product version", productController.PatchProduct, `{"version": 7}`, "version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+
+			middlewares.MergePatchBody(tt.handler).ServeHTTP(rr, newPatchRequest(tt.body))
+
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Expected status code 422 for a patch to an immutable field")
+			var problem problems.Problem
+			err := json.NewDecoder(rr.Body).Decode(&problem)
+			assert.NoError(t, err)
+			assert.Equal(t, problems.CodeImmutableField, problem.Code)
+			assert.Equal(t, []problems.FieldError{{Field: tt.field, Rule: "immutable"}}, problem.Errors)
+		})
+	}
+
+	stored, err := repos.Customers.GetByID(ctx, customer.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, user.ID, stored.UserID, "The owner must not change")
+		assert.Equal(t, "Ada", stored.FirstName, "A rejected patch must not write anything")
+	}
+}
@@ -0,0 +1,34 @@
+package unit_tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/metrics"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
+)
+
+func TestMetricsMiddleware_RecordsRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(middlewares.MetricsMiddleware)
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	router.HandleFunc("/v1/products/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/products/12", nil))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Expected status code 200 from the metrics endpoint")
+	body, err := io.ReadAll(rr.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `ecommerce_http_request_duration_seconds_count{method="GET",route="/v1/products/{id:[0-9]+}",status="404"} 1`)
+	assert.Contains(t, string(body), "ecommerce_orders_created_total")
+}
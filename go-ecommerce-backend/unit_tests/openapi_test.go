@@ -0,0 +1,107 @@
+package unit_tests
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/openapi"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/routes"
+)
+
+func newTestRouter() *mux.Router {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return routes.SetupRoutes(repositories.NewMemoryRepositories(repositories.NewMemoryStore()), &config.Config{Auth: config.AuthConfig{JWTSecret: "test-secret"}}, logger, nil, nil)
+}
+
+func TestOpenAPI_EveryRouteHasSpecEntry(t *testing.T) {
+	spec := routes.APISpec()
+	registered := map[string]bool{}
+
+	err := newTestRouter().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			key := method + " " + openapi.NormalizePath(path)
+			registered[key] = true
+
+			ops, ok := spec.Paths[openapi.NormalizePath(path)]
+			if !assert.True(t, ok, "route %s has no OpenAPI path entry", key) {
+				continue
+			}
+			_, ok = ops[strings.ToLower(method)]
+			assert.True(t, ok, "route %s has no OpenAPI operation", key)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	for _, op := range routes.APIOperations() {
+		key := op.Method + " " + openapi.NormalizePath(op.Path)
+		assert.True(t, registered[key], "OpenAPI operation %s has no registered route", key)
+	}
+}
+
+func TestOpenAPI_ValidateTagsBecomeConstraints(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rr, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]openapi.Schema `json:"schemas"`
+		} `json:"components"`
+	}
+	err := json.NewDecoder(rr.Body).Decode(&doc)
+	assert.NoError(t, err, "Expected valid JSON document")
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+
+	product := doc.Components.Schemas["ProductRequest"]
+	assert.ElementsMatch(t, []string{"name", "description", "category", "price", "stock"}, product.Required)
+	assert.Equal(t, 0.0, *product.Properties["price"].ExclusiveMinimum, "gt=0 should become exclusiveMinimum")
+
+	login := doc.Components.Schemas["LoginRequest"]
+	assert.Equal(t, "email", login.Properties["email"].Format, "email rule should become a format")
+	assert.Equal(t, 6, *login.Properties["password"].MinLength, "min=6 on a string should become minLength")
+
+	order := doc.Components.Schemas["OrderRequest"]
+	assert.Equal(t, []any{"pending", "completed", "cancelled"}, order.Properties["status"].Enum, "oneof should become an enum")
+}
+
+func TestOpenAPI_DocsServesSwaggerUI(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rr, httptest.NewRequest("GET", "/docs", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "swagger-ui")
+	assert.Contains(t, rr.Body.String(), `openapi.json"`)
+	assert.Contains(t, rr.Body.String(), `src="/docs/swagger-ui-bundle.js"`, "Swagger UI should load its script from the embedded assets")
+	assert.NotContains(t, rr.Body.String(), "unpkg.com", "Swagger UI must not depend on a CDN")
+}
+
+func TestOpenAPI_DocsServesEmbeddedAssets(t *testing.T) {
+	router := newTestRouter()
+	for _, path := range []string{"/docs/swagger-ui.css", "/docs/swagger-ui-bundle.js"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code, "Expected %s to be served", path)
+		assert.NotEmpty(t, rr.Body.Bytes(), "Expected %s to have a body", path)
+	}
+}
@@ -0,0 +1,123 @@
+package unit_tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+)
+
+func TestOrderService_CreateOrderSnapshotsOwnAddresses(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	orderService := services.NewOrderService(repos.Orders, repos.Customers, repos.Addresses, repos.Tx)
+	ctx := context.Background()
+
+	var customers []*models.Customer
+	var addresses []*models.Address
+	for _, email := range []string{"ada@example.com", "grace@example.com"} {
+		user := &models.User{Email: email, Password: "hash", Role: models.RoleCustomer}
+		assert.NoError(t, repos.Users.Create(ctx, user))
+		customer := &models.Customer{UserID: user.ID, FirstName: "Test", LastName: "Customer"}
+		assert.NoError(t, repos.Customers.Create(ctx, customer))
+		customers = append(customers, customer)
+		address := &models.Address{CustomerID: customer.ID, StreetAddress: "1 Main St", City: "London", Country: "UK"}
+		assert.NoError(t, repos.Addresses.Create(ctx, address))
+		addresses = append(addresses, address)
+	}
+	billing := &models.Address{CustomerID: customers[0].ID, StreetAddress: "9 Bank Rd", City: "Leeds", Country: "UK"}
+	assert.NoError(t, repos.Addresses.Create(ctx, billing))
+	product := &models.Product{Name: "Mug", Price: 9.5, Stock: 10}
+	assert.NoError(t, repos.Products.Create(ctx, product))
+
+	request := func(shippingID, billingID int) *services.OrderRequest {
+		return &services.OrderRequest{
+			CustomerID:        customers[0].ID,
+			Status:            models.OrderStatusPending,
+			OrderItems:        []services.OrderItemRequest{{ProductID: product.ID, Quantity: 1}},
+			ShippingAddressID: shippingID,
+			BillingAddressID:  billingID,
+		}
+	}
+
+	order, err := orderService.CreateOrder(ctx, request(addresses[0].ID, billing.ID))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &models.OrderAddress{AddressID: addresses[0].ID, StreetAddress: "1 Main St", City: "London", Country: "UK"}, order.ShippingAddress)
+	if assert.NotNil(t, order.BillingAddress) {
+		assert.Equal(t, "Leeds", order.BillingAddress.City)
+	}
+
+	_, err = orderService.CreateOrder(ctx, request(addresses[1].ID, 0))
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput, "Another customer's address must be rejected")
+	_, err = orderService.CreateOrder(ctx, request(addresses[0].ID, 999))
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput, "A missing address must be rejected")
+	missingCustomer := request(addresses[0].ID, 0)
+	missingCustomer.CustomerID = 999
+	_, err = orderService.CreateOrder(ctx, missingCustomer)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput, "A missing customer must be rejected as invalid input")
+	assert.NotErrorIs(t, err, apperrors.ErrNotFound)
+
+	withoutAddress, err := orderService.CreateOrder(ctx, request(0, 0))
+	if assert.NoError(t, err) {
+		assert.Nil(t, withoutAddress.ShippingAddress)
+		assert.Nil(t, withoutAddress.BillingAddress)
+	}
+
+	mug, err := repos.Products.GetByID(ctx, product.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 8, mug.Stock, "Rejected orders should not reserve stock")
+	}
+}
+
+func TestOrderService_CreateOrderChecksCaller(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	orderService := services.NewOrderService(repos.Orders, repos.Customers, repos.Addresses, repos.Tx)
+	ctx := context.Background()
+
+	var users []*models.User
+	var customers []*models.Customer
+	var addresses []*models.Address
+	for _, email := range []string{"ada@example.com", "mallory@example.com"} {
+		user := &models.User{Email: email, Password: "hash", Role: models.RoleCustomer}
+		assert.NoError(t, repos.Users.Create(ctx, user))
+		users = append(users, user)
+		customer := &models.Customer{UserID: user.ID, FirstName: "Test", LastName: "Customer"}
+		assert.NoError(t, repos.Customers.Create(ctx, customer))
+		customers = append(customers, customer)
+		address := &models.Address{CustomerID: customer.ID, StreetAddress: "1 Main St", City: "London", Country: "UK"}
+		assert.NoError(t, repos.Addresses.Create(ctx, address))
+		addresses = append(addresses, address)
+	}
+	product := &models.Product{Name: "Mug", Price: 9.5, Stock: 10}
+	assert.NoError(t, repos.Products.Create(ctx, product))
+
+	mallory := context.WithValue(ctx, middlewares.ContextUserID, users[1].ID)
+	request := func(customerID, shippingID, billingID int) *services.OrderRequest {
+		return &services.OrderRequest{
+			CustomerID:        customerID,
+			Status:            models.OrderStatusPending,
+			OrderItems:        []services.OrderItemRequest{{ProductID: product.ID, Quantity: 1}},
+			ShippingAddressID: shippingID,
+			BillingAddressID:  billingID,
+		}
+	}
+
+	_, err := orderService.CreateOrder(mallory, request(customers[0].ID, addresses[0].ID, 0))
+	assert.ErrorIs(t, err, apperrors.ErrForbidden, "Ordering for another user's customer must be forbidden")
+	_, err = orderService.CreateOrder(mallory, request(customers[1].ID, addresses[0].ID, 0))
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput, "Another user's shipping address must be rejected")
+	_, err = orderService.CreateOrder(mallory, request(customers[1].ID, addresses[1].ID, addresses[0].ID))
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput, "Another user's billing address must be rejected")
+
+	order, err := orderService.CreateOrder(mallory, request(customers[1].ID, addresses[1].ID, addresses[1].ID))
+	if assert.NoError(t, err) {
+		assert.Equal(t, addresses[1].ID, order.ShippingAddress.AddressID)
+	}
+}
@@ -2,6 +2,7 @@ package unit_tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -12,36 +13,54 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/controllers"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
 	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
 )
 
 type MockOrderService struct {
 	GetOrderByIDFunc          func(id int) (*models.Order, error)
-	GetOrdersByCustomerIDFunc func(id int) ([]*models.Order, error)
+	GetOrdersByCustomerIDFunc func(id int, query services.OrderListQuery) (*services.OrderPage, error)
+	ListOrdersFunc            func(query services.OrderListQuery) (*services.OrderPage, error)
+	ExportOrdersFunc          func(query services.OrderListQuery, fn func(order *models.Order) error) error
 	CreateOrderFunc           func(req *services.OrderRequest) (*models.Order, error)
 	UpdateOrderFunc           func(id int, req *services.OrderRequest) (*models.Order, error)
+	UpdateOrderStatusesFunc   func(req *services.OrderStatusesRequest) []services.OrderStatusResult
 	GetOwnerIDFunc            func(id int) (int, error)
 }
 
-func (m *MockOrderService) GetOrderByID(id int) (*models.Order, error) {
+func (m *MockOrderService) GetOrderByID(ctx context.Context, id int) (*models.Order, error) {
 	return m.GetOrderByIDFunc(id)
 }
 
-func (m *MockOrderService) GetOrdersByCustomerID(id int) ([]*models.Order, error) {
-	return m.GetOrdersByCustomerIDFunc(id)
+func (m *MockOrderService) GetOrdersByCustomerID(ctx context.Context, id int, query services.OrderListQuery) (*services.OrderPage, error) {
+	return m.GetOrdersByCustomerIDFunc(id, query)
 }
 
-func (m *MockOrderService) CreateOrder(req *services.OrderRequest) (*models.Order, error) {
+func (m *MockOrderService) ListOrders(ctx context.Context, query services.OrderListQuery) (*services.OrderPage, error) {
+	return m.ListOrdersFunc(query)
+}
+
+func (m *MockOrderService) ExportOrders(ctx context.Context, query services.OrderListQuery, fn func(order *models.Order) error) error {
+	return m.ExportOrdersFunc(query, fn)
+}
+
+func (m *MockOrderService) CreateOrder(ctx context.Context, req *services.OrderRequest) (*models.Order, error) {
 	return m.CreateOrderFunc(req)
 }
 
-func (m *MockOrderService) UpdateOrder(id int, req *services.OrderRequest) (*models.Order, error) {
+func (m *MockOrderService) UpdateOrder(ctx context.Context, id int, req *services.OrderRequest) (*models.Order, error) {
 	return m.UpdateOrderFunc(id, req)
 }
 
-func (m *MockOrderService) GetOwnerID(id int) (int, error) {
+func (m *MockOrderService) UpdateOrderStatuses(ctx context.Context, req *services.OrderStatusesRequest) []services.OrderStatusResult {
+	return m.UpdateOrderStatusesFunc(req)
+}
+
+func (m *MockOrderService) GetOwnerID(ctx context.Context, id int) (int, error) {
 	return m.GetOwnerIDFunc(id)
 }
 
@@ -110,7 +129,7 @@ func TestOrderController_GetOrder_InvalidID(t *testing.T) {
 func TestOrderController_GetOrder_NotFound(t *testing.T) {
 	mockService := &MockOrderService{
 		GetOrderByIDFunc: func(id int) (*models.Order, error) {
-			return &models.Order{}, errors.New("order not found")
+			return nil, apperrors.NotFound("order")
 		},
 	}
 	orderController := controllers.NewOrderController(mockService)
@@ -160,6 +179,32 @@ func TestOrderController_CreateOrder_Success(t *testing.T) {
 	assert.Equal(t, expectedOrder.ID, respOrder.ID, "Order ID should match")
 }
 
+func TestOrderController_CreateOrder_InsufficientStock(t *testing.T) {
+	mockService := &MockOrderService{
+		CreateOrderFunc: func(req *services.OrderRequest) (*models.Order, error) {
+			return nil, &apperrors.InsufficientStockError{ProductID: 1, Available: 1, Requested: 2}
+		},
+	}
+	orderController := controllers.NewOrderController(mockService)
+
+	orderReq := services.OrderRequest{
+		CustomerID: 1,
+		Status:     models.OrderStatusPending,
+		OrderItems: []services.OrderItemRequest{{ProductID: 1, Quantity: 2}},
+	}
+
+	req := httptest.NewRequest("POST", "/orders", nil)
+	rr := httptest.NewRecorder()
+
+	orderController.CreateOrder(rr, req, &orderReq)
+
+	assert.Equal(t, http.StatusConflict, rr.Code, "Expected status code 409 when stock is insufficient")
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err)
+	assert.Equal(t, problems.CodeInsufficientStock, problem.Code, "Expected insufficient_stock code")
+}
+
 func TestOrderController_UpdateOrder_Success(t *testing.T) {
 	localExpectedOrder := expectedOrder
 	localExpectedOrder.Status = models.OrderStatusCancelled
@@ -224,9 +269,9 @@ func TestOrderController_GetOrdersByCustomerID_Success(t *testing.T) {
 	expectedOrders := []*models.Order{order1, order2}
 
 	mockService := &MockOrderService{
-		GetOrdersByCustomerIDFunc: func(id int) ([]*models.Order, error) {
+		GetOrdersByCustomerIDFunc: func(id int, query services.OrderListQuery) (*services.OrderPage, error) {
 			if id == 1 {
-				return expectedOrders, nil
+				return &services.OrderPage{Orders: expectedOrders}, nil
 			}
 			return nil, errors.New("customer not found")
 		},
@@ -248,4 +293,75 @@ func TestOrderController_GetOrdersByCustomerID_Success(t *testing.T) {
 	assert.Equal(t, len(expectedOrders), len(respOrders), "Expected orders count to match")
 	assert.Equal(t, expectedOrders[0].ID, respOrders[0].ID, "Order ID should match for the first order")
 	assert.Equal(t, expectedOrders[1].ID, respOrders[1].ID, "Order ID should match for the second order")
+	assert.Empty(t, rr.Header().Get("Link"), "The last page should not link to a next page")
+}
+
+func TestOrderController_GetOrdersByCustomerID_ParsesQuery(t *testing.T) {
+	cursor := services.EncodeOrderCursor(repositories.OrderCursor{CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), ID: 7, Sort: repositories.OrderSortCreatedAt, Ascending: true})
+	var got services.OrderListQuery
+	mockService := &MockOrderService{
+		GetOrdersByCustomerIDFunc: func(id int, query services.OrderListQuery) (*services.OrderPage, error) {
+			got = query
+			return &services.OrderPage{Orders: []*models.Order{{ID: 6, CustomerID: 1, Status: models.OrderStatusCompleted}}, NextCursor: "next"}, nil
+		},
+	}
+	orderController := controllers.NewOrderController(mockService)
+
+	target := "/v1/customers/1/orders?status=pending,completed&created_after=2024-01-01T00:00:00Z&created_before=2024-06-01T00:00:00Z&sort=created_at&limit=5&view=summary&cursor=" + cursor
+	req := httptest.NewRequest("GET", target, nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	rr := httptest.NewRecorder()
+
+	orderController.GetOrdersByCustomerID(rr, req)
+
+	if !assert.Equal(t, http.StatusOK, rr.Code) {
+		return
+	}
+	assert.Equal(t, []models.OrderStatus{models.OrderStatusPending, models.OrderStatusCompleted}, got.Statuses)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), got.CreatedAfter)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), got.CreatedBefore)
+	assert.True(t, got.Ascending)
+	assert.Equal(t, 5, got.Limit)
+	assert.True(t, got.Summary)
+	if assert.NotNil(t, got.Cursor) {
+		assert.Equal(t, 7, got.Cursor.ID)
+	}
+
+	link := rr.Header().Get("Link")
+	assert.Contains(t, link, "cursor=next")
+	assert.Contains(t, link, "limit=5")
+	assert.Contains(t, link, `rel="next"`)
+
+	var body []map[string]any
+	if assert.NoError(t, json.NewDecoder(rr.Body).Decode(&body)) && assert.Len(t, body, 1) {
+		assert.NotContains(t, body[0], "order_items", "The summary view should omit items")
+	}
+}
+
+func TestOrderController_GetOrdersByCustomerID_InvalidQuery(t *testing.T) {
+	orderController := controllers.NewOrderController(&MockOrderService{})
+	totalCursor := services.EncodeOrderCursor(repositories.OrderCursor{CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Total: 10, ID: 7, Sort: repositories.OrderSortTotal})
+
+	for _, query := range []string{
+		"status=shipped",
+		"created_after=yesterday",
+		"sort=price",
+		"min_total=-1",
+		"product_id=abc",
+		"limit=0",
+		"limit=101",
+		"cursor=not-a-cursor",
+		"cursor=" + totalCursor,
+		"sort=total&cursor=" + totalCursor,
+		"view=compact",
+	} {
+		req := httptest.NewRequest("GET", "/v1/customers/1/orders?"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		rr := httptest.NewRecorder()
+
+		orderController.GetOrdersByCustomerID(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		assert.Contains(t, rr.Body.String(), `"code":"invalid_query"`, query)
+	}
 }
@@ -0,0 +1,95 @@
+package unit_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+)
+
+func TestOrderService_GetOrdersByCustomerID_Paginates(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store.Now = func() time.Time {
+		clock = clock.Add(time.Hour)
+		return clock
+	}
+	repos := repositories.NewMemoryRepositories(store)
+	orderService := services.NewOrderService(repos.Orders, repos.Customers, repos.Addresses, repos.Tx)
+	ctx := context.Background()
+
+	user := &models.User{Email: "ada@example.com", Password: "hash", Role: models.RoleCustomer}
+	assert.NoError(t, repos.Users.Create(ctx, user))
+	customer := &models.Customer{UserID: user.ID, FirstName: "Ada", LastName: "Lovelace"}
+	assert.NoError(t, repos.Customers.Create(ctx, customer))
+	product := &models.Product{Name: "Mug", Price: 9.5, Stock: 50}
+	assert.NoError(t, repos.Products.Create(ctx, product))
+
+	var created []int
+	for range 5 {
+		order, err := orderService.CreateOrder(ctx, &services.OrderRequest{
+			CustomerID: customer.ID,
+			Status:     models.OrderStatusPending,
+			OrderItems: []services.OrderItemRequest{{ProductID: product.ID, Quantity: 1}},
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		created = append([]int{order.ID}, created...)
+	}
+
+	var listed []int
+	query := services.OrderListQuery{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := orderService.GetOrdersByCustomerID(ctx, customer.ID, query)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for _, order := range page.Orders {
+			assert.Len(t, order.OrderItems, 1)
+			listed = append(listed, order.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor, err = services.DecodeOrderCursor(page.NextCursor)
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+	assert.Equal(t, created, listed, "Every order should be listed once, newest first")
+
+	page, err := orderService.GetOrdersByCustomerID(ctx, customer.ID, services.OrderListQuery{Summary: true})
+	if assert.NoError(t, err) && assert.Len(t, page.Orders, 5) {
+		assert.Empty(t, page.Orders[0].OrderItems)
+		assert.Empty(t, page.NextCursor)
+	}
+
+	page, err = orderService.GetOrdersByCustomerID(ctx, 999, services.OrderListQuery{})
+	if assert.NoError(t, err) {
+		assert.NotNil(t, page.Orders, "An empty history should encode as an empty list")
+		assert.Empty(t, page.Orders)
+	}
+}
+
+func TestOrderCursor_RoundTrip(t *testing.T) {
+	cursor := repositories.OrderCursor{CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC), Total: 12.5, ID: 42, Sort: repositories.OrderSortTotal, Ascending: true}
+
+	decoded, err := services.DecodeOrderCursor(services.EncodeOrderCursor(cursor))
+	if assert.NoError(t, err) {
+		assert.Equal(t, cursor, *decoded)
+	}
+
+	for _, value := range []string{"", "!!", "bm8tc2VwYXJhdG9y", "MjAyNHwx", "MjAyNC0wNS0wMVQxMjozMDowMFp8eA"} {
+		_, err := services.DecodeOrderCursor(value)
+		assert.ErrorIs(t, err, services.ErrInvalidCursor, value)
+	}
+}
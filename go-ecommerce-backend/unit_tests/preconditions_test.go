@@ -0,0 +1,175 @@
+package unit_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/controllers"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/preconditions"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+)
+
+func TestIfMatch_RequiredButMissing(t *testing.T) {
+	handler := middlewares.IfMatchMiddleware(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not be called without If-Match")
+	}))
+
+	req := httptest.NewRequest("PUT", "/v1/orders/1", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionRequired, rr.Code, "Expected status code 428 when If-Match is required")
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err)
+	assert.Equal(t, problems.CodePreconditionRequired, problem.Code, "Expected precondition_required code")
+}
+
+func TestIfMatch_InvalidTag(t *testing.T) {
+	handler := middlewares.IfMatchMiddleware(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not be called with an invalid If-Match")
+	}))
+
+	req := httptest.NewRequest("DELETE", "/v1/orders/1", nil)
+	req.Header.Set("If-Match", `"abc"`)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code, "Expected status code 412 for an invalid entity tag")
+}
+
+func TestIfMatch_StoresExpectedVersions(t *testing.T) {
+	var versions []int
+	var found bool
+	handler := middlewares.IfMatchMiddleware(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versions, found = preconditions.ExpectedVersions(r.Context())
+	}))
+
+	req := httptest.NewRequest("PUT", "/v1/orders/1", nil)
+	req.Header.Set("If-Match", preconditions.ETag(3)+`, W/"4", `+preconditions.ETag(5))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.True(t, found, "Expected versions should be set in the context")
+	assert.Equal(t, []int{3, 5}, versions, "Every strong entity tag in If-Match should be kept")
+}
+
+func TestIfMatch_Wildcard(t *testing.T) {
+	var called, found bool
+	handler := middlewares.IfMatchMiddleware(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		_, found = preconditions.ExpectedVersions(r.Context())
+	}))
+
+	req := httptest.NewRequest("DELETE", "/v1/orders/1", nil)
+	req.Header.Set("If-Match", "*")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.True(t, called, "If-Match: * should match any current representation")
+	assert.False(t, found, "If-Match: * should not constrain the version")
+}
+
+func TestIfMatch_WeakTagsNeverMatch(t *testing.T) {
+	handler := middlewares.IfMatchMiddleware(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not be called when If-Match has only weak entity tags")
+	}))
+
+	req := httptest.NewRequest("PATCH", "/v1/orders/1", nil)
+	req.Header.Set("If-Match", `W/"3"`)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code, "If-Match uses strong comparison")
+}
+
+func TestProductService_DeleteProduct_MatchesAnyListedVersion(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	productService := services.NewProductService(repos.Products)
+	ctx := t.Context()
+	product := &models.Product{Name: "Mug", Description: "Blue", Category: "kitchen", Price: 9.5, Stock: 3}
+	if !assert.NoError(t, repos.Products.Create(ctx, product)) {
+		return
+	}
+
+	err := productService.DeleteProduct(preconditions.ContextWithExpectedVersions(ctx, []int{7, 8}), product.ID)
+	assert.ErrorIs(t, err, apperrors.ErrPreconditionFailed, "No listed version matches")
+
+	err = productService.DeleteProduct(preconditions.ContextWithExpectedVersions(ctx, []int{7, product.Version}), product.ID)
+	assert.NoError(t, err, "Any listed version may match")
+	_, err = repos.Products.GetByID(ctx, product.ID)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func TestOrderController_UpdateOrder_StaleVersion(t *testing.T) {
+	mockService := &MockOrderService{
+		UpdateOrderFunc: func(id int, req *services.OrderRequest) (*models.Order, error) {
+			return nil, apperrors.PreconditionFailed("order")
+		},
+	}
+	orderController := controllers.NewOrderController(mockService)
+
+	req := httptest.NewRequest("PUT", "/orders/1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	rr := httptest.NewRecorder()
+
+	orderController.UpdateOrder(rr, req, &services.OrderRequest{CustomerID: 1, Status: models.OrderStatusCancelled})
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code, "Expected status code 412 for a stale version")
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err)
+	assert.Equal(t, problems.CodePreconditionFailed, problem.Code, "Expected precondition_failed code")
+}
+
+func TestOrderController_GetOrder_SetsETag(t *testing.T) {
+	order := expectedOrder
+	order.Version = 4
+	mockService := &MockOrderService{
+		GetOrderByIDFunc: func(id int) (*models.Order, error) {
+			return &order, nil
+		},
+	}
+	orderController := controllers.NewOrderController(mockService)
+
+	req := httptest.NewRequest("GET", "/orders/1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	rr := httptest.NewRecorder()
+
+	orderController.GetOrder(rr, req)
+
+	assert.Equal(t, `"4"`, rr.Header().Get("ETag"), "ETag should carry the order version")
+}
+
+func TestConditionalGet_NotModified(t *testing.T) {
+	handler := middlewares.ConditionalGet(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]string{"a", "b"})
+	})
+
+	first := httptest.NewRecorder()
+	handler(first, httptest.NewRequest("GET", "/v1/products", nil))
+	etag := first.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.NotEmpty(t, etag, "Expected an ETag on the first response")
+
+	req := httptest.NewRequest("GET", "/v1/products", nil)
+	req.Header.Set("If-None-Match", etag)
+	second := httptest.NewRecorder()
+	handler(second, req)
+
+	assert.Equal(t, http.StatusNotModified, second.Code, "Expected status code 304 for a matching If-None-Match")
+	assert.Empty(t, second.Body.String(), "A 304 response should have no body")
+}
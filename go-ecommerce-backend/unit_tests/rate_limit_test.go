@@ -0,0 +1,175 @@
+package unit_tests
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/config"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/ratelimit"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/routes"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+var testJWT = utils.JWTOptions{Secret: "test-secret", TTL: time.Hour}
+
+func newRateLimitedRouter(policies string, store ratelimit.Store) *mux.Router {
+	parsed, err := ratelimit.ParsePolicies(policies)
+	if err != nil {
+		panic(err)
+	}
+	cfg := ratelimit.Config{Enabled: true, Policies: parsed}
+
+	router := mux.NewRouter()
+	router.Use(middlewares.RateLimitMiddleware(cfg, store, testJWT))
+	router.HandleFunc("/v1/register", func(w http.ResponseWriter, r *http.Request) {}).Methods("POST")
+	router.HandleFunc("/v1/products", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	return router
+}
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ratelimit.ParsePolicies("default: 100/1m burst=20 key=user; POST /v1/register: 5/1h")
+
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Policy{Name: "default", Limit: 100, Window: time.Minute, Burst: 20, Key: ratelimit.KeyUser}, policies["default"])
+	assert.Equal(t, ratelimit.Policy{Name: "POST /v1/register", Limit: 5, Window: time.Hour, Key: ratelimit.KeyIP}, policies["POST /v1/register"])
+
+	_, err = ratelimit.ParsePolicies("default: 100 per minute")
+	assert.Error(t, err, "Expected an error for a malformed policy")
+}
+
+func TestMemoryStore_RefillsTokens(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := ratelimit.NewMemoryStore()
+	store.Now = func() time.Time { return now }
+	policy := ratelimit.Policy{Name: "test", Limit: 2, Window: time.Minute}
+
+	first, _ := store.Take(context.Background(), "client", policy)
+	second, _ := store.Take(context.Background(), "client", policy)
+	third, _ := store.Take(context.Background(), "client", policy)
+
+	assert.True(t, first.Allowed)
+	assert.True(t, second.Allowed)
+	assert.Equal(t, 0, second.Remaining)
+	assert.False(t, third.Allowed, "Bucket should be empty after two requests")
+	assert.Equal(t, 30*time.Second, third.RetryAfter, "One token is refilled every 30 seconds")
+
+	now = now.Add(30 * time.Second)
+	fourth, _ := store.Take(context.Background(), "client", policy)
+	assert.True(t, fourth.Allowed, "A token should be refilled after 30 seconds")
+}
+
+func TestRateLimitMiddleware_RejectsWithHeaders(t *testing.T) {
+	router := newRateLimitedRouter("POST /v1/register: 1/1h", ratelimit.NewMemoryStore())
+
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest("POST", "/v1/register", nil))
+	second := httptest.NewRecorder()
+	router.ServeHTTP(second, httptest.NewRequest("POST", "/v1/register", nil))
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1;w=3600", first.Header().Get("RateLimit-Policy"))
+
+	assert.Equal(t, http.StatusTooManyRequests, second.Code, "Expected status code 429 once the bucket is empty")
+	assert.Equal(t, "3600", second.Header().Get("Retry-After"))
+}
+
+func TestRateLimitMiddleware_ReportsQuotaNotBurst(t *testing.T) {
+	router := newRateLimitedRouter("GET /v1/products: 60/1m burst=30", ratelimit.NewMemoryStore())
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/products", nil))
+
+	assert.Equal(t, "60", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "29", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60;w=60", rr.Header().Get("RateLimit-Policy"))
+}
+
+func TestRateLimitMiddleware_SkipsOperationalEndpoints(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "test-secret"
+	cfg.RateLimit.Policies, _ = ratelimit.ParsePolicies("default: 1/1h")
+	router := routes.SetupRoutes(repositories.NewMemoryRepositories(repositories.NewMemoryStore()), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, ratelimit.NewMemoryStore())
+
+	for _, path := range []string{"/healthz", "/metrics", "/openapi.json", "/healthz"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+		assert.Empty(t, rr.Header().Get("RateLimit-Limit"), path)
+	}
+
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest("GET", "/v1/products", nil))
+	second := httptest.NewRecorder()
+	router.ServeHTTP(second, httptest.NewRequest("GET", "/v1/products", nil))
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusTooManyRequests, second.Code, "API routes should still be limited")
+}
+
+func TestRateLimitMiddleware_RoutesWithoutPolicy(t *testing.T) {
+	router := newRateLimitedRouter("POST /v1/register: 1/1h", ratelimit.NewMemoryStore())
+
+	for i := 0; i < 3; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/products", nil))
+		assert.Equal(t, http.StatusOK, rr.Code, "Routes without a policy should not be limited")
+		assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimitMiddleware_KeysByUser(t *testing.T) {
+	router := newRateLimitedRouter("default: 1/1m key=user", ratelimit.NewMemoryStore())
+	request := func(userID int) int {
+		req := httptest.NewRequest("GET", "/v1/products", nil)
+		token, _ := utils.GenerateJWT(userID, "customer", testJWT)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(1))
+	assert.Equal(t, http.StatusOK, request(2), "Different users from the same IP should have separate buckets")
+	assert.Equal(t, http.StatusTooManyRequests, request(1))
+}
+
+func TestRateLimitMiddleware_IgnoresSpoofedForwardedFor(t *testing.T) {
+	policies, _ := ratelimit.ParsePolicies("POST /v1/register: 1/1h")
+	var proxies ratelimit.TrustedProxies
+	if !assert.NoError(t, proxies.UnmarshalText([]byte("10.0.0.0/8"))) {
+		return
+	}
+	router := mux.NewRouter()
+	router.Use(middlewares.RateLimitMiddleware(ratelimit.Config{Enabled: true, TrustedProxies: proxies, Policies: policies}, ratelimit.NewMemoryStore(), testJWT))
+	router.HandleFunc("/v1/register", func(w http.ResponseWriter, r *http.Request) {}).Methods("POST")
+
+	request := func(remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest("POST", "/v1/register", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1234", "203.0.113.7"))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1:1234", "198.51.100.1, 203.0.113.7"), "A client-supplied entry must not get a fresh bucket")
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.2:1234", "198.51.100.2, 203.0.113.7, 10.0.0.1"), "Trusted hops should be skipped from the right")
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1234", "203.0.113.8"))
+
+	assert.Equal(t, http.StatusOK, request("192.0.2.1:1234", "198.51.100.3"))
+	assert.Equal(t, http.StatusTooManyRequests, request("192.0.2.1:1234", "198.51.100.4"), "X-Forwarded-For from an untrusted peer should be ignored")
+}
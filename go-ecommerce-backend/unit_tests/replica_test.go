@@ -0,0 +1,178 @@
+package unit_tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+func newTestCluster(t *testing.T, lag time.Duration, probeErr error) (*repositories.Cluster, *sql.DB, *sql.DB) {
+	primary, _ := sql.Open("postgres", "host=primary")
+	replica, _ := sql.Open("postgres", "host=replica")
+	t.Cleanup(func() {
+		primary.Close()
+		replica.Close()
+	})
+
+	cluster := repositories.NewCluster(primary, replica, repositories.ReplicaConfig{
+		MaxLag:     time.Second,
+		Stickiness: time.Minute,
+		Probe: func(ctx context.Context, db *sql.DB) (time.Duration, error) {
+			return lag, probeErr
+		},
+	})
+	return cluster, primary, replica
+}
+
+func TestCluster_ReadsFromHealthyReplica(t *testing.T) {
+	cluster, primary, replica := newTestCluster(t, 100*time.Millisecond, nil)
+
+	assert.Same(t, primary, cluster.Reader(context.Background()), "Reads should use the primary until the replica has been checked")
+
+	assert.NoError(t, cluster.CheckReplica(context.Background()))
+	assert.Same(t, replica, cluster.Reader(context.Background()))
+	assert.Same(t, primary, cluster.Reader(repositories.WithPrimary(context.Background())))
+}
+
+func TestCluster_FallsBackToPrimary(t *testing.T) {
+	tests := []struct {
+		name     string
+		lag      time.Duration
+		probeErr error
+	}{
+		{"replica down", 0, errors.New("connection refused")},
+		{"replica lagging", 5 * time.Second, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster, primary, _ := newTestCluster(t, tt.lag, tt.probeErr)
+
+			assert.Error(t, cluster.CheckReplica(context.Background()))
+			assert.False(t, cluster.ReplicaHealthy())
+			assert.Same(t, primary, cluster.Reader(context.Background()))
+		})
+	}
+}
+
+func TestCluster_WithoutReplica(t *testing.T) {
+	primary, _ := sql.Open("postgres", "host=primary")
+	defer primary.Close()
+	cluster := repositories.PrimaryOnly(primary)
+
+	assert.False(t, cluster.HasReplica())
+	assert.Same(t, primary, cluster.Reader(context.Background()))
+}
+
+func TestReadYourWritesMiddleware(t *testing.T) {
+	cluster, primary, replica := newTestCluster(t, 0, nil)
+	assert.NoError(t, cluster.CheckReplica(context.Background()))
+
+	var reader repositories.Querier
+	handler := middlewares.ReadYourWritesMiddleware(cluster, testJWT)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader = cluster.Reader(r.Context())
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	cookies := map[int][]*http.Cookie{}
+	send := func(method, target string, userID int) {
+		req := httptest.NewRequest(method, target, nil)
+		if userID != 0 {
+			token, _ := utils.GenerateJWT(userID, "customer", testJWT)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for _, cookie := range cookies[userID] {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if set := rr.Result().Cookies(); len(set) > 0 {
+			cookies[userID] = set
+		}
+	}
+
+	send(http.MethodGet, "/v1/products", 1)
+	assert.Same(t, replica, reader, "Reads without a recent write should use the replica")
+
+	send(http.MethodPost, "/v1/products?fail=1", 1)
+	assert.Same(t, primary, reader, "Mutations should always use the primary")
+	send(http.MethodGet, "/v1/products", 1)
+	assert.Same(t, replica, reader, "A failed mutation should not make reads sticky")
+
+	send(http.MethodPost, "/v1/products", 1)
+	send(http.MethodGet, "/v1/products", 1)
+	assert.Same(t, primary, reader, "Reads after the user's own write should use the primary")
+
+	send(http.MethodGet, "/v1/products", 2)
+	assert.Same(t, replica, reader, "Other users should keep reading from the replica")
+	send(http.MethodGet, "/v1/products", 0)
+	assert.Same(t, replica, reader, "Anonymous reads should use the replica")
+
+	cookies[2] = cookies[1]
+	send(http.MethodGet, "/v1/products", 2)
+	assert.Same(t, replica, reader, "A cookie issued to another user should be ignored")
+}
+
+func TestReadYourWritesMiddleware_StickinessIsStateless(t *testing.T) {
+	_, primary, replica := newTestCluster(t, 0, nil)
+	newInstance := func() (http.Handler, *repositories.Querier) {
+		cluster := repositories.NewCluster(primary, replica, repositories.ReplicaConfig{
+			MaxLag:     time.Second,
+			Stickiness: 20 * time.Millisecond,
+			Probe: func(ctx context.Context, db *sql.DB) (time.Duration, error) {
+				return 0, nil
+			},
+		})
+		assert.NoError(t, cluster.CheckReplica(context.Background()))
+		reader := new(repositories.Querier)
+		return middlewares.ReadYourWritesMiddleware(cluster, testJWT)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*reader = cluster.Reader(r.Context())
+			w.WriteHeader(http.StatusCreated)
+		})), reader
+	}
+	token, _ := utils.GenerateJWT(1, "customer", testJWT)
+	send := func(handler http.Handler, method string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/products", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	writer, _ := newInstance()
+	cookies := send(writer, http.MethodPost, nil).Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected a %s cookie, got %v", middlewares.ReadYourWritesCookie, cookies)
+	}
+
+	other, reader := newInstance()
+	send(other, http.MethodGet, cookies[0])
+	assert.Same(t, primary, *reader, "Another instance should honour the signed cookie")
+
+	tampered := *cookies[0]
+	tampered.Value = "9999999999999" + tampered.Value[strings.Index(tampered.Value, "."):]
+	send(other, http.MethodGet, &tampered)
+	assert.NotSame(t, primary, *reader, "A cookie with a forged expiry should be ignored")
+
+	time.Sleep(30 * time.Millisecond)
+	send(other, http.MethodGet, cookies[0])
+	assert.NotSame(t, primary, *reader, "Stickiness should end when the cookie expires")
+}
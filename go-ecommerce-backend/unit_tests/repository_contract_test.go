@@ -0,0 +1,107 @@
+package unit_tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories/repotest"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/seed"
+)
+
+func TestRepositoryContract_Memory(t *testing.T) {
+	repotest.RunContract(t, func(t *testing.T) *repositories.Repositories {
+		return repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	})
+}
+
+func TestMemoryRepositories_ConflictLogsOnlyTheConstraint(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	ctx := context.Background()
+	if !assert.NoError(t, repos.Users.Create(ctx, &models.User{Email: "ada@example.com", Password: "hash", Role: models.RoleCustomer})) {
+		return
+	}
+
+	err := repos.Users.Create(ctx, &models.User{Email: "ada@example.com", Password: "hash", Role: models.RoleCustomer})
+
+	assert.Error(t, err)
+	assert.Contains(t, logs.String(), "constraint=users_email_key")
+	assert.NotContains(t, logs.String(), "ada@example.com")
+}
+
+func TestMemoryRepositories_LoadSeedDataset(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	fixtures, err := seed.DefaultFixtures()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	summary, err := seed.NewSeeder(repos).Load(context.Background(), fixtures)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotZero(t, summary.Orders)
+	admin, err := repos.Users.GetByEmail(context.Background(), "admin@example.com")
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.RoleAdmin, admin.Role)
+	}
+}
+
+func TestSeeder_RollsBackOnFailure(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	fixtures, err := seed.DefaultFixtures()
+	if !assert.NoError(t, err) {
+		return
+	}
+	last := fixtures.Users[len(fixtures.Users)-1]
+	last.Customer = &seed.CustomerFixture{FirstName: "Broken", LastName: "Order", PhoneNumber: "555", Orders: []seed.OrderFixture{
+		{Status: models.OrderStatusPending, Items: []seed.OrderItemFixture{{Product: "No such product", Quantity: 1}}},
+	}}
+	fixtures.Users[len(fixtures.Users)-1] = last
+
+	_, err = seed.NewSeeder(repos).Load(context.Background(), fixtures)
+
+	assert.Error(t, err)
+	products, err := repos.Products.GetAll(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, products, "A failed seed should not leave products behind")
+	_, err = repos.Users.GetByEmail(context.Background(), fixtures.Users[0].Email)
+	assert.Error(t, err, "A failed seed should not leave users behind")
+}
+
+func TestSeeder_UpsertsProductsByName(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	fixtures, err := seed.DefaultFixtures()
+	if !assert.NoError(t, err) {
+		return
+	}
+	existing := &models.Product{Name: fixtures.Products[0].Name, Description: "old", Category: "old", Price: 1, Stock: 1}
+	if !assert.NoError(t, repos.Products.Create(context.Background(), existing)) {
+		return
+	}
+
+	_, err = seed.NewSeeder(repos).Load(context.Background(), fixtures)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	products, err := repos.Products.GetAll(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, products, len(fixtures.Products), "Existing products should be updated, not duplicated")
+	updated, err := repos.Products.GetByID(context.Background(), existing.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, fixtures.Products[0].Price, updated.Price)
+		assert.Equal(t, fixtures.Products[0].Description, updated.Description)
+	}
+}
@@ -0,0 +1,84 @@
+package unit_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/logging"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+func TestRequestLoggingMiddleware_LogsRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", "json")
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Use(middlewares.RequestIDMiddleware, middlewares.RequestLoggingMiddleware(logger))
+	router.HandleFunc("/v1/orders/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		logging.SetUserID(r.Context(), 7)
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short"))
+	}).Methods("GET")
+
+	req := httptest.NewRequest("GET", "/v1/orders/42", nil)
+	req.Header.Set(middlewares.RequestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, "abc-123", rr.Header().Get(middlewares.RequestIDHeader), "Request ID should be echoed back")
+
+	var entry map[string]any
+	err = json.Unmarshal(buf.Bytes(), &entry)
+	assert.NoError(t, err, "Expected a single JSON log line")
+	assert.Equal(t, "http request", entry["msg"])
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/v1/orders/{id:[0-9]+}", entry["route"], "Route template should be logged instead of the raw path")
+	assert.Equal(t, float64(http.StatusTeapot), entry["status"])
+	assert.Equal(t, float64(5), entry["bytes"])
+	assert.Equal(t, float64(7), entry["user_id"])
+	assert.Equal(t, "abc-123", entry["request_id"])
+	assert.Contains(t, entry, "latency")
+}
+
+func TestRequestIDMiddleware_GeneratesID(t *testing.T) {
+	handler := middlewares.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	assert.Len(t, rr.Header().Get(middlewares.RequestIDHeader), 32, "Expected a generated hex request ID")
+}
+
+func TestWriteJSON_LogsEncodeErrorsWithRequestContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", "json")
+	if !assert.NoError(t, err) {
+		return
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	req := httptest.NewRequest("GET", "/v1/products/1", nil)
+	req = req.WithContext(utils.ContextWithRequestID(req.Context(), "abc-123"))
+
+	utils.WriteJSON(httptest.NewRecorder(), req, map[string]any{"bad": make(chan int)})
+
+	var entry map[string]any
+	if !assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry)) {
+		return
+	}
+	assert.Equal(t, "failed to encode response", entry["msg"])
+	assert.Equal(t, "abc-123", entry["request_id"])
+	assert.Equal(t, "/v1/products/1", entry["path"])
+}
@@ -0,0 +1,75 @@
+package unit_tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/controllers"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+)
+
+type blockingProductRepository struct {
+	MockProductRepository
+}
+
+func (m *blockingProductRepository) GetByID(ctx context.Context, id int) (*models.Product, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func serveBlockedGetProduct(t *testing.T, ctx context.Context) (*httptest.ResponseRecorder, *bytes.Buffer) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	productController := controllers.NewProductController(services.NewProductService(&blockingProductRepository{}))
+	req := httptest.NewRequest("GET", "/v1/products/1", nil).WithContext(ctx)
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	rr := httptest.NewRecorder()
+
+	productController.GetProduct(rr, req)
+	return rr, &logs
+}
+
+func TestRequestTimeout_DeadlineReturnsServiceUnavailable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	rr, logs := serveBlockedGetProduct(t, ctx)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code, "Expected status code 503 when the request deadline expires")
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err)
+	assert.Equal(t, problems.CodeTimeout, problem.Code)
+	assert.Equal(t, "Request timed out", problem.Detail)
+	assert.Contains(t, logs.String(), "request timed out")
+	assert.NotContains(t, logs.String(), "request failed", "A timeout should not be logged as a server error")
+}
+
+func TestRequestTimeout_ClientCancelIsNotAServerError(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	rr, logs := serveBlockedGetProduct(t, ctx)
+
+	assert.Equal(t, problems.StatusClientClosedRequest, rr.Code, "Expected status code 499 when the client goes away")
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err)
+	assert.Equal(t, problems.CodeClientClosedRequest, problem.Code)
+	assert.Equal(t, "Client Closed Request", problem.Title)
+	assert.NotContains(t, logs.String(), "level=ERROR", "A client cancel should not be logged as a server error")
+}
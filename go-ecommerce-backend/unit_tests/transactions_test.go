@@ -0,0 +1,85 @@
+package unit_tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/apperrors"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/models"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/repositories"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/utils"
+)
+
+func TestAuthService_RegisterWithCustomerProfile(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	authService := services.NewAuthService(repos.Users, repos.Tx, utils.JWTOptions{})
+	ctx := context.Background()
+
+	err := authService.Register(ctx, &services.RegisterRequest{
+		Email:    "ada@example.com",
+		Password: "password",
+		Role:     models.RoleCustomer,
+		Customer: &services.RegisterCustomerRequest{FirstName: "Ada", LastName: "Lovelace", PhoneNumber: "555-0100"},
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	user, err := repos.Users.GetByEmail(ctx, "ada@example.com")
+	if !assert.NoError(t, err) {
+		return
+	}
+	customer, err := repos.Customers.GetByUserID(ctx, user.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Lovelace", customer.LastName)
+	}
+}
+
+func TestOrderService_CancelRestocksAtomically(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	orderService := services.NewOrderService(repos.Orders, repos.Customers, repos.Addresses, repos.Tx)
+	ctx := context.Background()
+
+	user := &models.User{Email: "ada@example.com", Password: "hash", Role: models.RoleCustomer}
+	assert.NoError(t, repos.Users.Create(ctx, user))
+	customer := &models.Customer{UserID: user.ID, FirstName: "Ada", LastName: "Lovelace"}
+	assert.NoError(t, repos.Customers.Create(ctx, customer))
+	product := &models.Product{Name: "Mug", Price: 9.5, Stock: 5}
+	assert.NoError(t, repos.Products.Create(ctx, product))
+
+	order, err := orderService.CreateOrder(ctx, &services.OrderRequest{
+		CustomerID: customer.ID,
+		Status:     models.OrderStatusPending,
+		OrderItems: []services.OrderItemRequest{{ProductID: product.ID, Quantity: 2}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cancelled, err := orderService.UpdateOrder(ctx, order.ID, &services.OrderRequest{Status: models.OrderStatusCancelled})
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.OrderStatusCancelled, cancelled.Status)
+		assert.Equal(t, 2, cancelled.Version)
+	}
+	stored, _ := repos.Products.GetByID(ctx, product.ID)
+	assert.Equal(t, 5, stored.Stock, "Cancelling should return the reserved stock")
+
+	_, err = orderService.UpdateOrder(ctx, order.ID, &services.OrderRequest{Status: models.OrderStatusCancelled})
+	assert.NoError(t, err)
+	stored, _ = repos.Products.GetByID(ctx, product.ID)
+	assert.Equal(t, 5, stored.Stock, "Cancelling twice should not restock twice")
+
+	_, err = orderService.UpdateOrder(ctx, order.ID, &services.OrderRequest{Status: models.OrderStatusPending})
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, repositories.IsRetryable(&pq.Error{Code: "40001"}))
+	assert.True(t, repositories.IsRetryable(&pq.Error{Code: "40P01"}))
+	assert.False(t, repositories.IsRetryable(&pq.Error{Code: "23505"}))
+	assert.False(t, repositories.IsRetryable(apperrors.ErrConflict))
+}
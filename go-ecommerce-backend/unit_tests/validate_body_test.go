@@ -0,0 +1,142 @@
+package unit_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/middlewares"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/problems"
+	"github.com/sergiustoicanescu/go-restAPI-backend/go-ecommerce-backend/services"
+)
+
+func TestValidateBody_ValidationFailure(t *testing.T) {
+	handler := middlewares.RequestIDMiddleware(middlewares.ValidateBody(func(w http.ResponseWriter, r *http.Request, req *services.OrderRequest) {
+		t.Fatal("handler should not be called for an invalid body")
+	}))
+
+	body := `{"customer_id": 1, "status": "shipped", "order_items": [{"product_id": 1, "quantity": 0}]}`
+	req := httptest.NewRequest("POST", "/v1/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middlewares.RequestIDHeader, "test-request-id")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Expected status code 400 for a body failing validation")
+	assert.Equal(t, problems.ContentType, rr.Header().Get("Content-Type"), "Expected problem+json content type")
+
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err, "Expected valid JSON response")
+	assert.Equal(t, problems.CodeValidationFailed, problem.Code, "Expected validation_failed code")
+	assert.Equal(t, "test-request-id", problem.RequestID, "Request ID should be propagated")
+	assert.Equal(t, "/v1/orders", problem.Instance, "Instance should be the request path")
+	assert.ElementsMatch(t, []problems.FieldError{
+		{Field: "status", Rule: "oneof", Param: "pending completed cancelled"},
+	}, problem.Errors, "Expected one error per failed rule")
+}
+
+func TestValidateBody_MalformedJSON(t *testing.T) {
+	handler := middlewares.ValidateBody(func(w http.ResponseWriter, r *http.Request, req *services.LoginRequest) {
+		t.Fatal("handler should not be called for malformed JSON")
+	})
+
+	req := httptest.NewRequest("POST", "/v1/login", strings.NewReader(`{"email":`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Expected status code 400 for malformed JSON")
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err, "Expected valid JSON response")
+	assert.Equal(t, problems.CodeInvalidBody, problem.Code, "Expected invalid_body code")
+	assert.Empty(t, problem.Errors, "Malformed JSON should not list field errors")
+}
+
+func newJSONRequest(body string) *http.Request {
+	req := httptest.NewRequest("POST", "/v1/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return req
+}
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) problems.Problem {
+	var problem problems.Problem
+	err := json.NewDecoder(rr.Body).Decode(&problem)
+	assert.NoError(t, err, "Expected valid JSON response")
+	return problem
+}
+
+func TestValidateBody_RejectsBadInput(t *testing.T) {
+	loginHandler := middlewares.ValidateBody(func(w http.ResponseWriter, r *http.Request, req *services.LoginRequest) {
+		t.Fatal("handler should not be called")
+	})
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+		code   problems.Code
+	}{
+		{"unknown field", newJSONRequest(`{"email": "a@b.com", "password": "secret", "pasword": "x"}`), http.StatusBadRequest, problems.CodeUnknownField},
+		{"trailing value", newJSONRequest(`{"email": "a@b.com", "password": "secret"} {"email": "c@d.com"}`), http.StatusBadRequest, problems.CodeTrailingData},
+		{"trailing garbage", newJSONRequest(`{"email": "a@b.com", "password": "secret"}garbage`), http.StatusBadRequest, problems.CodeTrailingData},
+		{"wrong content type", httptest.NewRequest("POST", "/v1/login", strings.NewReader(`{}`)), http.StatusUnsupportedMediaType, problems.CodeUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			loginHandler.ServeHTTP(rr, tt.req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.code, decodeProblem(t, rr).Code)
+		})
+	}
+}
+
+func TestValidateBody_UnknownFieldIsReported(t *testing.T) {
+	handler := middlewares.ValidateBody(func(w http.ResponseWriter, r *http.Request, req *services.LoginRequest) {
+		t.Fatal("handler should not be called")
+	})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, newJSONRequest(`{"email": "a@b.com", "password": "secret", "pasword": "x"}`))
+
+	assert.Equal(t, []problems.FieldError{{Field: "pasword", Rule: "unknown"}}, decodeProblem(t, rr).Errors)
+}
+
+func TestValidateBody_BodyTooLarge(t *testing.T) {
+	handler := middlewares.BodyLimitMiddleware(32)(middlewares.ValidateBody(func(w http.ResponseWriter, r *http.Request, req *services.LoginRequest) {
+		t.Fatal("handler should not be called for an oversize body")
+	}))
+
+	body := `{"email": "a@b.com", "password": "` + strings.Repeat("x", 64) + `"}`
+	chunked := newJSONRequest(body)
+	chunked.ContentLength = -1
+
+	for _, req := range []*http.Request{newJSONRequest(body), chunked} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code, "Expected status code 413 for an oversize body")
+		assert.Equal(t, problems.CodeBodyTooLarge, decodeProblem(t, rr).Code)
+	}
+}
+
+func TestValidateBody_RegisterRejectsAdminRole(t *testing.T) {
+	handler := middlewares.ValidateBody(func(w http.ResponseWriter, r *http.Request, req *services.RegisterRequest) {
+		t.Fatal("handler should not be called when registering an admin")
+	})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, newJSONRequest(`{"email": "eve@example.com", "password": "secret123", "role": "admin"}`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Public registration should not create admins")
+	assert.Equal(t, []problems.FieldError{{Field: "role", Rule: "oneof", Param: "customer"}}, decodeProblem(t, rr).Errors)
+}
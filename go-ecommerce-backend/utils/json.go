@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+func WriteJSON(w http.ResponseWriter, r *http.Request, v any) {
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.ErrorContext(r.Context(), "failed to encode response", "path", r.URL.Path, "error", err)
+	}
+}
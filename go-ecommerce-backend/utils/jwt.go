@@ -2,6 +2,7 @@ package utils
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -13,28 +14,43 @@ type Claims struct {
 	jwt.StandardClaims
 }
 
-func GenerateJWT(userID int, role, secret string) (string, error) {
+type JWTOptions struct {
+	Secret string
+	TTL    time.Duration
+	Issuer string
+}
+
+func GenerateJWT(userID int, role string, opts JWTOptions) (string, error) {
+	now := time.Now()
 	claims := Claims{
 		UserID: userID,
 		Role:   role,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(72 * time.Hour).Unix(),
-			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: now.Add(opts.TTL).Unix(),
+			IssuedAt:  now.Unix(),
+			Issuer:    opts.Issuer,
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return token.SignedString([]byte(opts.Secret))
 }
 
-func ValidateJWT(tokenStr, secret string) (*Claims, error) {
+func ValidateJWT(tokenStr string, opts JWTOptions) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (any, error) {
-		return []byte(secret), nil
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte(opts.Secret), nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if opts.Issuer != "" && !claims.VerifyIssuer(opts.Issuer, true) {
+		return nil, errors.New("invalid token issuer")
 	}
-	return nil, errors.New("invalid token")
+	return claims, nil
 }
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type requestIDKey struct{}
+
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}